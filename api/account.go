@@ -0,0 +1,309 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Account management: username change, account deletion and data download.
+package api
+
+import (
+	"archive/zip"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/replay"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Returns courses that the user has review DBs for.
+func userCourses(userID int) []Course {
+	pattern := filepath.Join(basedir.User(userID), "reviews", "*.db")
+	matches, _ := filepath.Glob(pattern)
+
+	var courses []Course
+	for _, match := range matches {
+		code := strings.TrimSuffix(filepath.Base(match), ".db")
+		l1, l2, found := strings.Cut(code, "-")
+		if found {
+			courses = append(courses, Course{
+				L1: Language{Code: l1},
+				L2: Language{Code: l2},
+			})
+		}
+	}
+	return courses
+}
+
+// Closes pooled review DBs and drops prefetched flashcards of the user.
+// Should be called before the user's files get deleted.
+func forgetUser(r *http.Request, userID int) {
+	for _, course := range userCourses(userID) {
+		invalidateReviewDB(r, userID, course.L1.Code, course.L2.Code)
+		invalidateFlashcards(r, userID, course.L1.Code, course.L2.Code)
+	}
+}
+
+// Deletes user from the auth DB, and deletes the user's files.
+func deleteAccount(db *sql.DB, userID int) error {
+	// Move files out of the way first, so that they can be restored if the
+	// user can't be deleted.
+	// User IDs can get reused, so the files shouldn't stay where a new user
+	// would look for them.
+	dir := basedir.User(userID)
+	trash := dir + ".deleted"
+	moved := true
+	if err := os.Rename(dir, trash); err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to delete account: %w", err)
+		}
+		moved = false
+	}
+
+	if err := auth.DeleteUser(db, userID); err != nil {
+		if moved {
+			_ = os.Rename(trash, dir)
+		}
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	if moved {
+		if err := os.RemoveAll(trash); err != nil {
+			return fmt.Errorf("failed to delete user files: %w", err)
+		}
+	}
+	return nil
+}
+
+func handleDeleteAccount(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Account settings can only be changed from browser sessions.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() || s.IsStateless() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	username := s.Data["username"].(string)
+	csrfToken := r.FormValue("csrf-token")
+	password := r.FormValue("password")
+	confirm := r.FormValue("confirm")
+
+	// Check CSRF token.
+	if !s.CheckCSRFToken(csrfToken) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"delete-account",
+		)
+		goto fail
+	}
+
+	// Check confirmation string.
+	if confirm != username {
+		_ = s.ErrorMessage("Incorrect confirmation string.", "delete-account")
+		goto fail
+	}
+
+	if _, message := authenticateThrottled(r, db, username, password); message != "" {
+		_ = s.ErrorMessage(message, "delete-account")
+		goto fail
+	}
+
+	forgetUser(r, userID)
+	if err := deleteAccount(db, userID); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"delete-account",
+		)
+		goto fail
+	}
+
+	// The session was deleted with the user, but the cookie is still there.
+	_ = sessions.EndSession(db, w, r)
+	http.Redirect(w, r, "/about", http.StatusSeeOther)
+	return
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+func handleChangeUsername(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Account settings can only be changed from browser sessions.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() || s.IsStateless() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	username := s.Data["username"].(string)
+	csrfToken := r.FormValue("csrf-token")
+	password := r.FormValue("password")
+	newUsername := strings.TrimSpace(r.FormValue("new-username"))
+
+	// Check CSRF token.
+	if !s.CheckCSRFToken(csrfToken) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"account",
+		)
+		goto fail
+	}
+
+	if newUsername == "" || newUsername == username {
+		_ = s.ErrorMessage("Enter a new username.", "account")
+		goto fail
+	}
+
+	if _, message := authenticateThrottled(r, db, username, password); message != "" {
+		_ = s.ErrorMessage(message, "account")
+		goto fail
+	}
+
+	if err := auth.ChangeUsername(db, userID, newUsername); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"This username is unavailable. Try another one.",
+			"account",
+		)
+		goto fail
+	}
+	_ = s.SuccessMessage("Username updated.", "account")
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
+
+// Adds a consistent copy of the DB's main schema to the zip archive.
+// Safer than copying the file, because the DB may be in use.
+func addDBToZip(zw *zip.Writer, name string, db *sql.DB) error {
+	dir, err := os.MkdirTemp("", "polycloze-export-")
+	if err != nil {
+		return fmt.Errorf("failed to add %v to archive: %w", name, err)
+	}
+	defer os.RemoveAll(dir)
+
+	snapshot := filepath.Join(dir, "snapshot.db")
+	if _, err := db.Exec(`VACUUM main INTO ?`, snapshot); err != nil {
+		return fmt.Errorf("failed to add %v to archive: %w", name, err)
+	}
+
+	file, err := os.Open(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to add %v to archive: %w", name, err)
+	}
+	defer file.Close()
+
+	entry, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %v to archive: %w", name, err)
+	}
+	if _, err := io.Copy(entry, file); err != nil {
+		return fmt.Errorf("failed to add %v to archive: %w", name, err)
+	}
+	return nil
+}
+
+// Adds review DB and review history (CSV) of the course to the zip archive.
+func addCourseToZip(r *http.Request, zw *zip.Writer, userID int, course Course) error {
+	l1, l2 := course.L1.Code, course.L2.Code
+	code := fmt.Sprintf("%v-%v", l1, l2)
+
+	db, release, err := openReviewDB(r, userID, l1, l2)
+	if err != nil {
+		return fmt.Errorf("could not open review database (%v): %w", code, err)
+	}
+	defer release()
+
+	if err := addDBToZip(zw, "reviews/"+code+".db", db); err != nil {
+		return err
+	}
+
+	entry, err := zw.Create("exports/" + code + ".csv")
+	if err != nil {
+		return fmt.Errorf("failed to add %v.csv to archive: %w", code, err)
+	}
+	if err := replay.ExportCSV(db, entry); err != nil {
+		return err
+	}
+
+	if !hasCustomDB(userID, l1, l2) {
+		return nil
+	}
+	custom, err := database.OpenCustomDB(basedir.Custom(userID, l1, l2))
+	if err != nil {
+		return fmt.Errorf("could not open custom sentence database (%v): %w", code, err)
+	}
+	defer custom.Close()
+	return addDBToZip(zw, "custom/"+code+".db", custom)
+}
+
+// Writes zip archive of the user's data.
+func writeUserArchive(r *http.Request, w io.Writer, userID int) error {
+	zw := zip.NewWriter(w)
+
+	db, err := database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		return fmt.Errorf("failed to open user database: %w", err)
+	}
+	defer db.Close()
+	if err := addDBToZip(zw, "user.db", db); err != nil {
+		return err
+	}
+
+	for _, course := range userCourses(userID) {
+		if !courseExists(course.L1.Code, course.L2.Code) {
+			continue
+		}
+		if err := addCourseToZip(r, zw, userID, course); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// Downloads zip archive of the user's data.
+func handleDownloadData(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "expected GET request", http.StatusBadRequest)
+		return
+	}
+
+	// Account data can only be downloaded from browser sessions.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() || s.IsStateless() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	username := s.Data["username"].(string)
+
+	filename := fmt.Sprintf("polycloze-%v.zip", username)
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Headers have already been sent, so errors can only be logged.
+	if err := writeUserArchive(r, w, userID); err != nil {
+		log.Println(err)
+	}
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+func TestAddDBToZip(t *testing.T) {
+	t.Parallel()
+
+	db, err := database.OpenUserDB(filepath.Join(t.TempDir(), "user.db"))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer db.Close()
+	if err := setUserSetting(db, "timezone", "Asia/Manila"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := addDBToZip(zw, "user.db", db); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	// Extract snapshot and check its contents.
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(zr.File) != 1 || zr.File[0].Name != "user.db" {
+		t.Fatal("expected archive to contain user.db")
+	}
+	entry, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer entry.Close()
+	data, err := io.ReadAll(entry)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	name := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := os.WriteFile(name, data, 0o600); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	snapshot, err := database.OpenUserDB(name)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer snapshot.Close()
+
+	timezone, err := getUserSetting(snapshot, "timezone")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if timezone != "Asia/Manila" {
+		t.Fatal("expected snapshot to contain user settings:", timezone)
+	}
+}
+
+func TestBearerTokenCannotChangeUsername(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	if err := auth.Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	userID, err := auth.Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	token, err := auth.CreateAccessToken(db, userID, "test")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(auth.Middleware(db))
+	r.Use(auth.BearerMiddleware(db))
+	r.HandleFunc("/api/settings/account/rename", handleChangeUsername)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	v := url.Values{}
+	v.Set("password", "bar")
+	v.Set("new-username", "baz")
+	req, err := http.NewRequest("POST", resolve(ts, "/api/settings/account/rename"), strings.NewReader(v.Encode()))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatal("expected bearer token to be rejected:", resp.StatusCode)
+	}
+	if _, err := auth.Authenticate(db, "foo", "bar"); err != nil {
+		t.Fatal("expected username to be unchanged:", err)
+	}
+}
+
+func TestBearerTokenCannotChangePassword(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	if err := auth.Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	userID, err := auth.Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	token, err := auth.CreateAccessToken(db, userID, "test")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	r := chi.NewRouter()
+	r.Use(auth.Middleware(db))
+	r.Use(auth.BearerMiddleware(db))
+	r.HandleFunc("/settings", handleSettings)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	// Stateless sessions have no ID, so this token is easy to compute.
+	v := url.Values{}
+	v.Set("csrf-token", sessions.CSRFToken(""))
+	v.Set("current-password", "bar")
+	v.Set("new-password", "new password")
+	req, err := http.NewRequest("POST", resolve(ts, "/settings"), strings.NewReader(v.Encode()))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	resp.Body.Close()
+	if _, err := auth.Authenticate(db, "foo", "bar"); err != nil {
+		t.Fatal("expected password to be unchanged:", err)
+	}
+}
@@ -50,7 +50,7 @@ func handleSetCourse(w http.ResponseWriter, r *http.Request) {
 
 	// Check csrf token.
 	token := r.Header.Get("X-CSRF-Token")
-	if !sessions.CheckCSRFToken(s.ID, token) {
+	if !s.CheckAPICSRFToken(token) {
 		http.Error(w, "Forbidden.", http.StatusForbidden)
 		return
 	}
@@ -7,6 +7,7 @@ import (
 	"database/sql"
 	"log"
 	"net/http"
+	"os"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -16,12 +17,28 @@ import (
 )
 
 // Middleware
-func cors(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Headers", "Accept, Content-Type")
-		next.ServeHTTP(w, r)
-	})
+func cors(origins []string) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool)
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			switch {
+			case allowed["*"]:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case allowed[origin]:
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+				w.Header().Add("Vary", "Origin")
+			default:
+				next.ServeHTTP(w, r)
+				return
+			}
+			w.Header().Set("Access-Control-Allow-Headers", "Accept, Authorization, Content-Type")
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
 func handleHome(w http.ResponseWriter, r *http.Request) {
@@ -115,11 +132,24 @@ func handleVocabularyPage(w http.ResponseWriter, r *http.Request) {
 // db: user DB for authentication
 func Router(config Config, db *sql.DB) (chi.Router, error) {
 	r := chi.NewRouter()
-	if config.AllowCORS {
-		r.Use(cors)
+	if len(config.CORSOrigins) > 0 {
+		r.Use(cors(config.CORSOrigins))
+	}
+	if config.RequestLog != nil {
+		r.Use(middleware.RequestLogger(&middleware.DefaultLogFormatter{
+			Logger:  log.New(config.RequestLog, "", log.LstdFlags),
+			NoColor: config.RequestLog != os.Stdout,
+		}))
+	}
+	if config.RateLimit > 0 {
+		r.Use(rateLimitMiddleware(config.RateLimit, config.RateLimitBurst, config.TrustProxy, config.ClientIPHeader))
 	}
-	r.Use(middleware.Logger)
 	r.Use(auth.Middleware(db))
+	r.Use(auth.BearerMiddleware(db))
+	r.Use(configMiddleware(config))
+
+	r.HandleFunc("/healthz", handleHealthz)
+	r.HandleFunc("/readyz", handleReadyz)
 
 	r.HandleFunc("/", handleHome)
 	r.HandleFunc("/study", handleStudy)
@@ -131,6 +161,7 @@ func Router(config Config, db *sql.DB) (chi.Router, error) {
 	r.HandleFunc("/register", handleRegister)
 	r.HandleFunc("/signin", handleSignIn)
 	r.HandleFunc("/signout", handleSignOut)
+	r.HandleFunc("/reset", handleResetPassword)
 
 	r.Handle("/dist/*", http.StripPrefix("/dist/", serveDist()))
 	r.Handle("/public/*", http.StripPrefix("/public/", servePublic()))
@@ -146,9 +177,14 @@ func Router(config Config, db *sql.DB) (chi.Router, error) {
 
 	r.HandleFunc("/api/flashcards/{l1}/{l2}", handleFlashcards)
 	r.HandleFunc("/api/vocabulary/{l1}/{l2}", handleVocabulary)
+	r.HandleFunc("/api/vocabulary/{l1}/{l2}/actions", handleWordAction)
 	r.HandleFunc("/api/stats/activity/{l1}/{l2}", handleStatsActivity)
 	r.HandleFunc("/api/stats/vocab/{l1}/{l2}", handleStatsVocab)
 	r.HandleFunc("/api/stats/estimate/{l1}/{l2}", handleStatsEstimatedLevel)
+	r.HandleFunc("/api/export/{l1}/{l2}", handleExport)
+	r.HandleFunc("/api/custom/{l1}/{l2}", handleCustomSentences)
+	r.HandleFunc("/api/custom/{l1}/{l2}/delete", handleDeleteCustomSentence)
+	r.HandleFunc("/api/tts/{l1}/{l2}", handleTTS)
 
 	r.HandleFunc("/api/languages", serveLanguagesJSON())
 	r.HandleFunc("/api/courses", serveCoursesJSON())
@@ -156,5 +192,17 @@ func Router(config Config, db *sql.DB) (chi.Router, error) {
 	r.HandleFunc("/api/actions/set-course", handleSetCourse)
 	r.HandleFunc("/api/settings/upload/{l1}/{l2}", handleUpload)
 	r.HandleFunc("/api/settings/reset/{l1}/{l2}", handleResetProgress)
+	r.HandleFunc("/api/settings/scheduler/{l1}/{l2}", handleSetScheduler)
+	r.HandleFunc("/api/settings/lemma/{l1}/{l2}", handleSetLemmaMode)
+	r.HandleFunc("/api/settings/leeches/{l1}/{l2}", handleSetLeechThreshold)
+	r.HandleFunc("/api/settings/limits/{l1}/{l2}", handleSetLimits)
+	r.HandleFunc("/api/settings/account/rename", handleChangeUsername)
+	r.HandleFunc("/api/settings/account/delete", handleDeleteAccount)
+	r.HandleFunc("/api/settings/account/export", handleDownloadData)
+	r.HandleFunc("/api/settings/sessions/revoke", handleRevokeSession)
+	r.HandleFunc("/api/settings/tokens/create", handleCreateAccessToken)
+	r.HandleFunc("/api/settings/tokens/revoke", handleRevokeAccessToken)
+
+	r.HandleFunc("/api/admin/reset-links", handleCreateResetLink)
 	return r, nil
 }
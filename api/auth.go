@@ -5,16 +5,57 @@
 package api
 
 import (
+	"database/sql"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
 	"os"
 	"path"
+	"time"
 
 	"github.com/polycloze/polycloze/auth"
 	"github.com/polycloze/polycloze/basedir"
 	"github.com/polycloze/polycloze/sessions"
 )
 
+// Returns error message for throttled clients.
+func tooManyAttempts(wait time.Duration) string {
+	seconds := int(math.Ceil(wait.Seconds()))
+	return fmt.Sprintf("Too many attempts. Try again in %v.", time.Duration(seconds)*time.Second)
+}
+
+// Error message from `authenticateThrottled` for wrong passwords.
+const incorrectPassword = "Incorrect password."
+
+// Checks the user's password, with the same throttle as sign-ins to prevent
+// password guessing.
+// The attempt is recorded before the password is checked, so that parallel
+// requests can't get past the throttle.
+// Returns the user ID, or an error message for the user.
+func authenticateThrottled(r *http.Request, db *sql.DB, username, password string) (int, string) {
+	ip := getConfig(r).clientIP(r)
+	attempt, wait, err := auth.TakeAttempt(db, ip, username, auth.ActionSignIn, time.Now())
+	if err != nil {
+		log.Println(err)
+		return 0, "Authentication failed."
+	}
+	if wait > 0 {
+		return 0, tooManyAttempts(wait)
+	}
+
+	userID, err := auth.Authenticate(db, username, password)
+	if err != nil {
+		return 0, incorrectPassword
+	}
+
+	// Successful attempts don't count.
+	if err := auth.ForgetAttempt(db, attempt); err != nil {
+		log.Println(err)
+	}
+	return userID, ""
+}
+
 // HandlerFunc for user registrations.
 func handleRegister(w http.ResponseWriter, r *http.Request) {
 	// Redirect to home page if already signed in.
@@ -33,15 +74,44 @@ func handleRegister(w http.ResponseWriter, r *http.Request) {
 		password := r.FormValue("password")
 		csrfToken := r.FormValue("csrf-token")
 
-		if !sessions.CheckCSRFToken(s.ID, csrfToken) {
+		if !s.CheckCSRFToken(csrfToken) {
 			_ = s.ErrorMessage("Something went wrong. Please try again.", "register")
 			goto fail
 		}
-		if auth.Register(db, username, password) == nil {
+		policy := getConfig(r).Registration
+		if policy == RegistrationClosed {
+			goto fail
+		}
+		if message := getConfig(r).checkPassword(password); message != "" {
+			_ = s.ErrorMessage(message, "register")
+			goto fail
+		}
+
+		// Every attempt costs a bcrypt hash, so they're all throttled.
+		ip := getConfig(r).clientIP(r)
+		if _, wait, err := auth.TakeAttempt(db, ip, username, auth.ActionRegister, time.Now()); err != nil {
+			log.Println(err)
+			_ = s.ErrorMessage("Something went wrong. Please try again.", "register")
+			goto fail
+		} else if wait > 0 {
+			_ = s.ErrorMessage(tooManyAttempts(wait), "register")
+			goto fail
+		}
+
+		if policy == RegistrationInvite {
+			err = auth.RegisterWithInviteCode(db, username, password, r.FormValue("invite-code"))
+		} else {
+			err = auth.Register(db, username, password)
+		}
+		if err == nil {
 			// `StatusTemporaryRedirect` also resends POST data to the next page.
 			http.Redirect(w, r, "/signin", http.StatusTemporaryRedirect)
 			return
 		}
+		if auth.IsInvalidInviteCode(err) {
+			_ = s.ErrorMessage("Invalid invite code.", "register")
+			goto fail
+		}
 		_ = s.ErrorMessage(
 			"This username is unavailable. Try another one.",
 			"register",
@@ -53,6 +123,8 @@ fail:
 	data := map[string]any{
 		"csrfToken": sessions.CSRFToken(s.ID),
 		"messages":  messages,
+		"closed":    getConfig(r).Registration == RegistrationClosed,
+		"invite":    getConfig(r).Registration == RegistrationInvite,
 	}
 	renderTemplate(w, "register.html", data)
 }
@@ -76,15 +148,22 @@ func handleSignIn(w http.ResponseWriter, r *http.Request) {
 		password := r.FormValue("password")
 		csrfToken := r.FormValue("csrf-token")
 
-		if !sessions.CheckCSRFToken(s.ID, csrfToken) {
+		if !s.CheckCSRFToken(csrfToken) {
 			_ = s.ErrorMessage("Authentication failed.", "sign-in")
 			goto fail
 		}
-		userID, err := auth.Authenticate(db, username, password)
-		if err != nil {
-			_ = s.ErrorMessage("Incorrect username or password.", "sign-in")
+
+		userID, message := authenticateThrottled(r, db, username, password)
+		if message != "" {
+			if message == incorrectPassword {
+				message = "Incorrect username or password."
+			}
+			_ = s.ErrorMessage(message, "sign-in")
 			goto fail
 		}
+		if err := auth.ClearFailedSignIns(db, username); err != nil {
+			log.Println(err)
+		}
 
 		s.Data["userID"] = userID
 		s.Data["username"] = username
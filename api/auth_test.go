@@ -69,3 +69,59 @@ func TestRegisterNoCSRFToken(t *testing.T) {
 		t.Fatal("expected form button text to be 'Register':", text)
 	}
 }
+
+func TestCheckPassword(t *testing.T) {
+	t.Parallel()
+
+	config := Config{
+		PasswordPolicy: auth.PasswordPolicy{
+			MinLength: 8,
+			Breached:  map[string]bool{"password": true},
+		},
+	}
+	if message := config.checkPassword("short"); message == "" {
+		t.Fatal("expected short password to be rejected")
+	}
+	if message := config.checkPassword("password"); message == "" {
+		t.Fatal("expected breached password to be rejected")
+	}
+	if message := config.checkPassword("correct horse"); message != "" {
+		t.Fatal("expected password to be allowed:", message)
+	}
+	if message := (Config{}).checkPassword(""); message == "" {
+		t.Fatal("expected empty password to be rejected by zero config")
+	}
+}
+
+func TestAuthenticateThrottled(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	if err := auth.Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	r := httptest.NewRequest("POST", "/api/settings/account/rename", nil)
+
+	// Successful attempts don't count.
+	for i := 0; i < 5; i++ {
+		if _, message := authenticateThrottled(r, db, "foo", "bar"); message != "" {
+			t.Fatal("expected correct password to be accepted:", message)
+		}
+	}
+
+	// Keep guessing until the throttle kicks in.
+	throttled := false
+	for i := 0; i < 10 && !throttled; i++ {
+		_, message := authenticateThrottled(r, db, "foo", "wrong")
+		throttled = message != incorrectPassword
+	}
+	if !throttled {
+		t.Fatal("expected repeated wrong passwords to be throttled")
+	}
+
+	// The correct password doesn't get past the throttle either.
+	if _, message := authenticateThrottled(r, db, "foo", "bar"); message == "" {
+		t.Fatal("expected password check to be throttled")
+	}
+}
@@ -3,7 +3,124 @@
 
 package api
 
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/flashcards"
+	"github.com/polycloze/polycloze/mailer"
+	"github.com/polycloze/polycloze/tts"
+)
+
+// Registration policies.
+const (
+	RegistrationOpen   = "open"
+	RegistrationInvite = "invite"
+	RegistrationClosed = "closed"
+)
+
+// Default limits used by the zero config.
+const (
+	defaultMaxUploadSize = 8 * 1024 * 1024
+	defaultMaxSentences  = 1000
+)
+
 type Config struct {
-	AllowCORS bool
-	Port      int
+	// Origins allowed to make cross-origin requests. "*" allows every origin.
+	CORSOrigins []string
+
+	// Max size of uploaded files in bytes.
+	// Uses a default value if zero.
+	MaxUploadSize int64
+
+	// Max number of sentences returned by /api/sentences.
+	// Uses a default value if zero.
+	MaxSentences int
+
+	// Max number of API requests per client per minute.
+	// Disabled if zero.
+	RateLimit      int
+	RateLimitBurst int
+
+	// Who can create accounts. Registration is open if empty.
+	Registration string
+
+	// Rules for new passwords.
+	// Only empty passwords are rejected if zero.
+	PasswordPolicy auth.PasswordPolicy
+
+	// Trust the X-Forwarded-For header when identifying clients.
+	// Only enable behind a reverse proxy that sets the header.
+	TrustProxy bool
+
+	// Header that a trusted reverse proxy sets to the client's IP address
+	// (e.g. Fly-Client-IP). Takes precedence over TrustProxy.
+	ClientIPHeader string
+
+	// Public URL of the server (e.g. "https://example.com").
+	// Password reset links are disabled if empty.
+	PublicURL string
+
+	// Secret for admin endpoints, sent in the X-Admin-Token header.
+	// Admin endpoints are disabled if empty.
+	AdminToken string
+
+	// Delivers password reset links.
+	// Disabled if nil.
+	Mailer mailer.Mailer
+
+	// Request logs are written here.
+	// Requests aren't logged if nil.
+	RequestLog io.Writer
+
+	// Pool of review DB handles shared between requests.
+	// Handlers open and close review DBs on every request if nil.
+	Registry *database.Registry
+
+	// Generates flashcards in the background.
+	// Flashcards are only generated during requests if nil.
+	Prefetcher *flashcards.Prefetcher
+
+	// Generates sentence audio that isn't in the course DB.
+	// Disabled if nil.
+	TTS *tts.Command
+}
+
+// Returns error message to show if the password isn't allowed.
+// Returns an empty string if the password is OK.
+func (c Config) checkPassword(password string) string {
+	err := c.PasswordPolicy.Check(password)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, auth.ErrPasswordTooShort):
+		minLength := c.PasswordPolicy.MinLength
+		if minLength < 1 {
+			minLength = 1
+		}
+		return fmt.Sprintf("Password should have at least %v characters.", minLength)
+	case errors.Is(err, auth.ErrPasswordTooLong):
+		return "Password is too long."
+	case errors.Is(err, auth.ErrBreachedPassword):
+		return "This password has appeared in a data breach. Choose another one."
+	default:
+		return "Invalid password."
+	}
+}
+
+func (c Config) maxUploadSize() int64 {
+	if c.MaxUploadSize <= 0 {
+		return defaultMaxUploadSize
+	}
+	return c.MaxUploadSize
+}
+
+func (c Config) maxSentences() int {
+	if c.MaxSentences <= 0 {
+		return defaultMaxSentences
+	}
+	return c.MaxSentences
 }
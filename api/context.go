@@ -0,0 +1,35 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"context"
+	"net/http"
+)
+
+type contextValueKey int
+
+// Keys for getting values from request context.
+const (
+	keyConfig contextValueKey = iota
+)
+
+// Stuffs server config into request context.
+func configMiddleware(config Config) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), keyConfig, &config)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Gets server config from request context.
+// Returns the zero config if configMiddleware isn't used.
+func getConfig(r *http.Request) *Config {
+	if config, ok := r.Context().Value(keyConfig).(*Config); ok {
+		return config
+	}
+	return &Config{}
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// User-defined sentences.
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/custom"
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Checks if user has a custom sentence DB for the course.
+func hasCustomDB(userID int, l1, l2 string) bool {
+	_, err := os.Stat(basedir.Custom(userID, l1, l2))
+	return err == nil
+}
+
+// Opens user's custom sentence DB.
+// Creates the DB and its parent directory if they don't exist yet.
+func openCustomDB(name string) (*sql.DB, error) {
+	if err := os.MkdirAll(path.Dir(name), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create custom sentence directory: %w", err)
+	}
+	return database.OpenCustomDB(name)
+}
+
+// Handles custom sentence requests.
+// GET: lists custom sentences.
+// POST: adds a custom sentence (JSON body: CustomSentenceRequest).
+func handleCustomSentences(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" && r.Method != "POST" {
+		http.Error(w, "expected GET or POST request", http.StatusBadRequest)
+		return
+	}
+	if r.Method == "POST" && r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "expected JSON body in POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	name := basedir.Custom(userID, l1, l2)
+
+	if r.Method == "GET" {
+		// Don't create the DB if the user hasn't added any sentences yet.
+		if _, err := os.Stat(name); err != nil {
+			sendJSON(w, CustomSentencesResponse{Sentences: []custom.Sentence{}})
+			return
+		}
+
+		db, err := database.OpenCustomDB(name)
+		if err != nil {
+			log.Println(fmt.Errorf("could not open custom sentence database (%v-%v): %w", l1, l2, err))
+			http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+			return
+		}
+		defer db.Close()
+
+		sentences, err := custom.List(db)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, CustomSentencesResponse{Sentences: sentences})
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Could not read request.", http.StatusInternalServerError)
+		return
+	}
+
+	var data CustomSentenceRequest
+	if err := parseJSON(w, body, &data); err != nil {
+		return
+	}
+
+	// Look for csrf token in request headers or in the request body.
+	token := r.Header.Get("X-CSRF-Token")
+	if token == "" {
+		token = data.CSRFToken
+	}
+	if !s.CheckAPICSRFToken(token) {
+		http.Error(w, "Forbidden.", http.StatusForbidden)
+		return
+	}
+
+	db, err = openCustomDB(name)
+	if err != nil {
+		log.Println(fmt.Errorf("could not open custom sentence database (%v-%v): %w", l1, l2, err))
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	sentence, err := custom.Add(db, data.Text, data.Translation)
+	switch {
+	case errors.Is(err, custom.ErrEmpty):
+		http.Error(w, "Sentence and translation can't be empty.", http.StatusBadRequest)
+		return
+	case errors.Is(err, custom.ErrTooLong):
+		http.Error(w, "Sentence is too long.", http.StatusBadRequest)
+		return
+	case err != nil:
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	invalidateFlashcards(r, userID, l1, l2)
+	sendJSON(w, sentence)
+}
+
+// Deletes custom sentence.
+// Expects JSON body (CustomSentenceRequest with ID).
+func handleDeleteCustomSentence(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "expected JSON body in POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Could not read request.", http.StatusInternalServerError)
+		return
+	}
+
+	var data CustomSentenceRequest
+	if err := parseJSON(w, body, &data); err != nil {
+		return
+	}
+
+	// Look for csrf token in request headers or in the request body.
+	token := r.Header.Get("X-CSRF-Token")
+	if token == "" {
+		token = data.CSRFToken
+	}
+	if !s.CheckAPICSRFToken(token) {
+		http.Error(w, "Forbidden.", http.StatusForbidden)
+		return
+	}
+
+	name := basedir.Custom(userID, l1, l2)
+	if _, err := os.Stat(name); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	db, err = database.OpenCustomDB(name)
+	if err != nil {
+		log.Println(fmt.Errorf("could not open custom sentence database (%v-%v): %w", l1, l2, err))
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	defer db.Close()
+
+	if err := custom.Delete(db, data.ID); err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	invalidateFlashcards(r, userID, l1, l2)
+	sendJSON(w, OkResponse{Ok: true})
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Signed-in devices.
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+func handleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() || s.IsStateless() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+
+	if !s.CheckCSRFToken(r.FormValue("csrf-token")) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"sessions",
+		)
+		goto fail
+	}
+
+	if id, err := strconv.ParseInt(r.FormValue("id"), 10, 64); err != nil {
+		_ = s.ErrorMessage("Invalid session.", "sessions")
+		goto fail
+	} else if err := sessions.RevokeSession(db, userID, id); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage("Invalid session.", "sessions")
+		goto fail
+	}
+
+	_ = s.SuccessMessage("Device signed out.", "sessions")
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
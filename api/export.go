@@ -0,0 +1,77 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Review history export.
+package api
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/replay"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Streams user's review history.
+// Query params: format (csv, jsonl or anki; csv by default).
+func handleExport(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "expected GET request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+
+	format := replay.FormatCSV
+	if value := r.URL.Query().Get("format"); value != "" {
+		format, err = replay.ParseFormat(value)
+		if err != nil {
+			http.Error(w, "Unknown export format.", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Open user's review DB.
+	db, release, err := openReviewDB(r, userID, l1, l2)
+	if err != nil {
+		log.Println(fmt.Errorf("could not open review database (%v-%v): %w", l1, l2, err))
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	contentType := "text/plain; charset=utf-8"
+	switch format {
+	case replay.FormatCSV:
+		contentType = "text/csv; charset=utf-8"
+	case replay.FormatJSONL:
+		contentType = "application/x-ndjson"
+	}
+	filename := fmt.Sprintf("%v-%v.%v", l1, l2, format.Extension())
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	// Headers have already been sent, so errors can only be logged.
+	if err := replay.Export(db, w, format); err != nil {
+		log.Println(err)
+	}
+}
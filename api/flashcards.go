@@ -17,6 +17,7 @@ import (
 	"github.com/polycloze/polycloze/database"
 	"github.com/polycloze/polycloze/difficulty"
 	"github.com/polycloze/polycloze/flashcards"
+	rs "github.com/polycloze/polycloze/review_scheduler"
 	"github.com/polycloze/polycloze/sessions"
 	"github.com/polycloze/polycloze/text"
 	"github.com/polycloze/polycloze/word_scheduler"
@@ -34,6 +35,60 @@ func excludeWords(words []string) func(string) bool {
 	}
 }
 
+// Returns words in flashcards.
+func itemWords(items []flashcards.Item) []string {
+	var words []string
+	for _, item := range items {
+		words = append(words, item.Words()...)
+	}
+	return words
+}
+
+// Returns the quota left after handing out the flashcards.
+func quotaAfter[T database.Querier](
+	q T,
+	quota word_scheduler.Quota,
+	items []flashcards.Item,
+	now time.Time,
+) (word_scheduler.Quota, error) {
+	newWords, reviews, err := word_scheduler.CountOutstanding(q, itemWords(items), now)
+	if err != nil {
+		return quota, err
+	}
+	return quota.Minus(newWords, reviews), nil
+}
+
+// Returns excluded words that weren't answered in the request.
+// These are flashcards that the client is still holding on to.
+func outstandingWords(exclude []string, reviews []ReviewResult) []string {
+	answered := make(map[string]bool)
+	for _, review := range reviews {
+		answered[text.Casefold(review.Word)] = true
+	}
+
+	var words []string
+	for _, word := range exclude {
+		word = text.Casefold(word)
+		if !answered[word] {
+			words = append(words, word)
+		}
+	}
+	return words
+}
+
+// Returns the card kind in the request.
+// Multi-word flashcards can only be cloze cards.
+func requestedKind(data FlashcardsRequest) (rs.Kind, error) {
+	kind, err := rs.ParseKind(data.Kind)
+	if err != nil {
+		return kind, err
+	}
+	if data.Multi && !kind.IsCloze() {
+		return kind, fmt.Errorf("invalid card kind in multi mode: %v", kind)
+	}
+	return kind, nil
+}
+
 func handleFlashcards(w http.ResponseWriter, r *http.Request) {
 	// Check request method and content type.
 	if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
@@ -59,17 +114,16 @@ func handleFlashcards(w http.ResponseWriter, r *http.Request) {
 
 	// Open user's review DB.
 	userID := s.Data["userID"].(int)
-	db, err = database.OpenReviewDB(basedir.Review(userID, l1, l2))
+	db, release, err := openReviewDB(r, userID, l1, l2)
 	if err != nil {
 		log.Println(fmt.Errorf("could not open review database (%v-%v): %w", l1, l2, err))
 		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
+	defer release()
 
-	// Create database connection with access to review and course DB.
-	hook := database.AttachCourse(basedir.Course(l1, l2))
-	con, err := database.NewConnection(db, r.Context(), hook)
+	// Course and custom sentence DBs are already attached to pooled handles.
+	con, err := database.NewConnection(db, r.Context())
 	if err != nil {
 		log.Println(err)
 		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
@@ -77,6 +131,15 @@ func handleFlashcards(w http.ResponseWriter, r *http.Request) {
 	}
 	defer con.Close()
 
+	// Course settings are all read from the same handle.
+	userDB, err := database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	defer userDB.Close()
+
 	// Read request data.
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -90,6 +153,12 @@ func handleFlashcards(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	kind, err := requestedKind(data)
+	if err != nil {
+		http.Error(w, "Invalid card kind.", http.StatusBadRequest)
+		return
+	}
+
 	// Save uploaded reviews and difficulty stats.
 	if len(data.Reviews) > 0 {
 		// Look for csrf token in request headers or in the request body.
@@ -99,18 +168,35 @@ func handleFlashcards(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Check csrf token.
-		if !sessions.CheckCSRFToken(s.ID, token) {
+		if !s.CheckAPICSRFToken(token) {
 			http.Error(w, "Forbidden.", http.StatusForbidden)
 			return
 		}
 
+		for _, review := range data.Reviews {
+			if err := rs.CheckGrade(review.Grade, review.Correct); err != nil {
+				http.Error(w, "Invalid review result.", http.StatusBadRequest)
+				return
+			}
+		}
+
 		// Save review results.
-		if err := word_scheduler.BulkSaveWords(con, data.Reviews, time.Now()); err != nil {
+		scheduler, err := getScheduler(userDB, l1, l2)
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+			return
+		}
+		if err := word_scheduler.BulkSaveWords(con, scheduler, data.Reviews, time.Now()); err != nil {
 			log.Println(err)
 			http.Error(w, "Something went wrong.", http.StatusInternalServerError)
 			return
 		}
 
+		// Prefetched flashcards were picked before the reviews changed the
+		// schedule, and new words were picked for the old difficulty level.
+		invalidateFlashcards(r, userID, l1, l2)
+
 		if data.Difficulty != nil {
 			if err := difficulty.Update(con, *data.Difficulty); err != nil {
 				log.Println(err)
@@ -120,11 +206,66 @@ func handleFlashcards(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	lemmaMode, err := isLemmaMode(userDB, l1, l2)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	// Count today's reviews after saving the uploaded ones.
+	outstanding := outstandingWords(data.Exclude, data.Reviews)
+	quota, err := getQuota(userDB, l1, l2, con, outstanding, time.Now())
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
 	// Generate flashcards.
-	items := flashcards.Get(con, data.Limit, excludeWords(data.Exclude))
+	var items []flashcards.Item
+	if !kind.IsCloze() {
+		// Other card kinds aren't prefetched, and don't count towards daily
+		// limits, because they have their own review tracks.
+		items = flashcards.GetKind(con, kind, data.Limit, excludeWords(data.Exclude))
+	} else if lemmaMode {
+		// Lemma flashcards aren't prefetched either, and only blank one word
+		// per sentence.
+		items = flashcards.GetLemmasWithQuota(con, data.Limit, quota, excludeWords(data.Exclude))
+	} else if data.Multi {
+		items = flashcards.GetMultiWithQuota(con, data.Limit, quota, excludeWords(data.Exclude))
+	} else {
+		// Answer from prefetched flashcards first.
+		remaining := quota
+		items = takeFlashcards(r, userID, l1, l2, data.Limit, excludeWords(data.Exclude))
+		items = flashcards.ApplyQuota(items, &remaining)
+		if len(items) < data.Limit {
+			exclude := append(data.Exclude, itemWords(items)...)
+			more := flashcards.GetWithQuota(con, data.Limit-len(items), remaining, excludeWords(exclude))
+			items = append(items, more...)
+		}
+
+		// Prepare flashcards for the next request.
+		if data.Limit > 0 {
+			exclude := append(data.Exclude, itemWords(items)...)
+			prefetchFlashcards(r, userID, l1, l2, exclude)
+		}
+	}
+	addTTSAudio(r, l1, l2, items)
 	newDiff := difficulty.GetLatest(con)
-	sendJSON(w, FlashcardsResponse{
+	response := FlashcardsResponse{
 		Items:      items,
 		Difficulty: &newDiff,
-	})
+	}
+	if quota != word_scheduler.Unlimited && kind.IsCloze() {
+		// Report what's left after the flashcards in this response.
+		quota, err = quotaAfter(con, quota, items, time.Now())
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+			return
+		}
+		response.Quota = &quota
+	}
+	sendJSON(w, response)
 }
@@ -0,0 +1,89 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/flashcards"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/word_scheduler"
+)
+
+func TestOutstandingWords(t *testing.T) {
+	// Words answered in the same request aren't outstanding anymore.
+	t.Parallel()
+
+	exclude := []string{"Foo", "bar", "baz"}
+	reviews := []ReviewResult{{Word: "foo", Correct: true}, {Word: "Baz"}}
+	words := outstandingWords(exclude, reviews)
+	if !reflect.DeepEqual(words, []string{"bar"}) {
+		t.Fatal("expected only unanswered words:", words)
+	}
+}
+
+func TestRequestedKind(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		data  FlashcardsRequest
+		kind  rs.Kind
+		valid bool
+	}{
+		{FlashcardsRequest{}, rs.Cloze, true},
+		{FlashcardsRequest{Multi: true}, rs.Cloze, true},
+		{FlashcardsRequest{Kind: "production"}, rs.Production, true},
+		{FlashcardsRequest{Kind: "production", Multi: true}, "", false},
+		{FlashcardsRequest{Kind: "foo"}, "", false},
+	}
+	for _, c := range cases {
+		kind, err := requestedKind(c.data)
+		if c.valid && (err != nil || kind != c.kind) {
+			t.Fatal("expected valid card kind:", c.data, kind, err)
+		}
+		if !c.valid && err == nil {
+			t.Fatal("expected invalid card kind:", c.data, kind)
+		}
+	}
+}
+
+// Returns flashcard that blanks the words.
+func testItem(words ...string) flashcards.Item {
+	var parts []flashcards.Part
+	for _, word := range words {
+		answer := flashcards.Answer{Text: word, Normalized: word}
+		parts = append(parts, flashcards.Part{Text: word, Answers: []flashcards.Answer{answer}})
+	}
+	return flashcards.Item{Sentence: flashcards.Sentence{Parts: parts}}
+}
+
+func TestQuotaAfter(t *testing.T) {
+	// Returned flashcards use up the quota reported to the client.
+	t.Parallel()
+
+	db, err := database.OpenReviewDB(filepath.Join(t.TempDir(), "review.db"))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	if err := rs.UpdateReviewAt(db, "due", true, now.Add(-30*24*time.Hour)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	quota := word_scheduler.Quota{NewWords: 5, Reviews: 2}
+	items := []flashcards.Item{testItem("foo", "due"), testItem("bar")}
+	quota, err = quotaAfter(db, quota, items, now)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if quota.NewWords != 3 || quota.Reviews != 1 {
+		t.Fatal("expected quota to be used up by returned flashcards:", quota)
+	}
+}
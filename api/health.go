@@ -0,0 +1,65 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Health and readiness probes.
+package api
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/polycloze/polycloze/auth"
+)
+
+// Checks if the auth DB can be queried.
+func checkAuthDB(ctx context.Context, db *sql.DB) error {
+	if db == nil {
+		return errors.New("auth DB not found")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	var one int
+	if err := db.QueryRowContext(ctx, `SELECT 1`).Scan(&one); err != nil {
+		return fmt.Errorf("failed to query auth DB: %w", err)
+	}
+	return nil
+}
+
+// Checks if courses were loaded at startup.
+func checkCourses(courses []Course) error {
+	if len(courses) == 0 {
+		return errors.New("no courses loaded")
+	}
+	return nil
+}
+
+// Liveness probe.
+// Succeeds as long as the server can handle requests.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprintln(w, "ok")
+}
+
+// Readiness probe.
+// Fails if the auth DB isn't available or if no courses were loaded at startup.
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	err := checkAuthDB(r.Context(), auth.GetDB(r))
+	if err == nil {
+		err = checkCourses(loadedCourses)
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckAuthDB(t *testing.T) {
+	t.Parallel()
+
+	db := testDB()
+	if err := checkAuthDB(context.Background(), db); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	db.Close()
+	if err := checkAuthDB(context.Background(), db); err == nil {
+		t.Fatal("expected closed DB to fail readiness check")
+	}
+}
+
+func TestCheckCourses(t *testing.T) {
+	t.Parallel()
+
+	if err := checkCourses(nil); err == nil {
+		t.Fatal("expected empty course list to fail readiness check")
+	}
+
+	courses := []Course{{L1: Language{Code: "eng"}, L2: Language{Code: "spa"}}}
+	if err := checkCourses(courses); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+}
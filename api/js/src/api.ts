@@ -1,5 +1,6 @@
 // Wrappers for api calls.
 
+import { CardKind } from "./cards";
 import { csrf } from "./csrf";
 import { day, endOfDay } from "./datetime";
 import { Difficulty } from "./difficulty";
@@ -35,6 +36,7 @@ type FetchVocabularyOptions = {
   limit?: number; // Max number of items to fetch
   after?: string; // Last item to exclude from query
   sortBy?: "word" | "reviewed" | "due" | "strength";
+  filter?: "leech"; // Only list leeches
 };
 
 function defaultFetchVocabularyOptions(): FetchVocabularyOptions {
@@ -50,12 +52,12 @@ function defaultFetchVocabularyOptions(): FetchVocabularyOptions {
 export async function fetchVocabulary(
   options: FetchVocabularyOptions = {}
 ): Promise<Word[]> {
-  const { l1, l2, limit, after, sortBy } = {
+  const { l1, l2, limit, after, sortBy, filter } = {
     ...defaultFetchVocabularyOptions(),
     ...options,
   };
   const url = resolve(`/api/vocabulary/${l1}/${l2}`);
-  setParams(url, { after, limit, sortBy });
+  setParams(url, { after, limit, sortBy, filter });
 
   const json = await fetchJson<VocabularySchema>(url, {
     mode: "cors" as RequestMode,
@@ -63,6 +65,29 @@ export async function fetchVocabulary(
   return json.words || [];
 }
 
+export type WordAction =
+  | "suspend"
+  | "unsuspend"
+  | "drill"
+  | "reset"
+  | "bury"
+  | "unbury"
+  | "known";
+
+// Sets state of a word (e.g. suspends a leech or marks a word as known).
+// `days`: number of days to bury the word, or interval of known word.
+export async function submitWordAction(
+  word: string,
+  action: WordAction,
+  days?: number
+): Promise<boolean> {
+  const l1 = getL1().code;
+  const l2 = getL2().code;
+  const url = resolve(`/api/vocabulary/${l1}/${l2}/actions`);
+  const json = await submitJson<{ ok: boolean }>(url, { word, action, days });
+  return json.ok;
+}
+
 type FetchActivityOptions = {
   l1?: string;
   l2?: string;
@@ -202,6 +227,8 @@ type FetchFlashcardsOptions = {
   exclude?: string[]; // Words to exclude in flashcards
   reviews?: ReviewResult[];
   difficulty?: Difficulty;
+  multi?: boolean; // Blank multiple words per sentence
+  kind?: CardKind; // Card kind (cloze if omitted)
 };
 
 function defaultFetchFlashcardsOptions(): FetchFlashcardsOptions {
@@ -216,8 +243,8 @@ function defaultFetchFlashcardsOptions(): FetchFlashcardsOptions {
 
 // Returns a copy of the review result containing only the necessary fields.
 function minimizeReviewResult(review: ReviewResult): ReviewResult {
-  const { word, correct, timestamp } = review;
-  return { word, correct, timestamp };
+  const { word, correct, grade, kind, timestamp } = review;
+  return { word, correct, grade, kind, timestamp };
 }
 
 export function fetchFlashcards(
@@ -234,6 +261,8 @@ export function fetchFlashcards(
         ? options.reviews.map(minimizeReviewResult)
         : undefined,
     difficulty: options.difficulty,
+    multi: options.multi,
+    kind: options.kind,
     timestamp: Math.floor(Date.now() / 1000),
   };
   return submitJson<FlashcardsResponse>(url, data);
@@ -302,6 +331,7 @@ export async function fetchSentences(
 type UploadCSVFileOptions = {
   l1?: string;
   l2?: string;
+  merge?: boolean; // Merge with existing reviews
 };
 
 function defaultUploadCSVFileOptions(): UploadCSVFileOptions {
@@ -319,11 +349,15 @@ export function uploadCSVFile(
   file: File,
   options: UploadCSVFileOptions = {}
 ): Promise<UploadCSVFileResponse> {
-  const { l1, l2 } = { ...defaultUploadCSVFileOptions(), ...options };
+  options = { ...defaultUploadCSVFileOptions(), ...options };
+  const { l1, l2 } = options;
 
   const formData = new FormData();
   formData.append("csrf-token", csrf());
   formData.append(name, file);
+  if (options.merge) {
+    formData.append("merge", "true");
+  }
 
   const url = resolve(`/api/settings/upload/${l1}/${l2}`);
   return submitFormData<UploadCSVFileResponse>(url, formData);
@@ -1,6 +1,7 @@
 import "./app.css";
 import { ItemBuffer } from "./buffer";
-import { createEmptyItem, createItem } from "./item";
+import { createEmptyItem, createItem, playItemAudio } from "./item";
+import { isListeningMode } from "./listening";
 import { TTS } from "./tts";
 
 export async function createApp(
@@ -30,6 +31,10 @@ export async function createApp(
     const blank = div.querySelector(".blank") as HTMLInputElement;
     blank.focus();
     resize();
+
+    if (isListeningMode()) {
+      playItemAudio(tts, item);
+    }
   };
   return [div, ready];
 }
@@ -6,13 +6,40 @@ import { distance } from "fastest-levenshtein";
 
 type Status = "correct" | "incorrect" | "almost";
 
+export type Alternative = {
+  text: string;
+  correct: boolean; // Near miss if false
+};
+
 export type Answer = {
   text: string;
   normalized: string;
   new: boolean;
   difficulty: number;
+  alternatives?: Alternative[];
 };
 
+// Returns answer that matches the guess exactly or through an alternative.
+// Also returns whether the match counts as fully correct.
+export function matchAnswer(
+  guess: string,
+  answers: Answer[]
+): [Answer, boolean] | null {
+  for (const answer of answers) {
+    if (compare(guess, answer.text) === 0) {
+      return [answer, true];
+    }
+  }
+  for (const answer of answers) {
+    for (const alternative of answer.alternatives || []) {
+      if (compare(guess, alternative.text) === 0) {
+        return [answer, alternative.correct];
+      }
+    }
+  }
+  return null;
+}
+
 export type Part = {
   text: string;
   answers?: Answer[];
@@ -85,27 +112,25 @@ export function compare(guess: string, answer: string): number {
 // May throw an exception if `part` doesn't have answers.
 //
 // Summary:
-// - Correct if matches exactly with a possible answer
-// - Almost correct if similar to preferred answer
+// - Correct if matches exactly with a possible answer or correct alternative
+// - Almost correct if it's a near miss, or if similar to preferred answer
 // - Incorrect otherwise
 export function evaluateInput(
   input: HTMLInputElement,
   part: PartWithAnswers
 ): Status {
   const answers = part.answers;
-  const diffs = [];
-  for (const answer of answers) {
-    const diff = compare(input.value, answer.text);
-    if (diff === 0) {
-      // Return immediately if exact match is found.
-      changeStatus(input, "correct");
-      return "correct";
-    }
-    diffs.push(diff);
+
+  // Return immediately if exact match or accepted alternative is found.
+  const match = matchAnswer(input.value, answers);
+  if (match != null) {
+    const status = match[1] ? "correct" : "almost";
+    changeStatus(input, status);
+    return status;
   }
 
   // Only allow typos in preferred answer.
-  if (diffs[0] <= 2) {
+  if (compare(input.value, answers[0].text) <= 2) {
     changeStatus(input, "almost");
     return "almost";
   }
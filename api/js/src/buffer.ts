@@ -2,6 +2,7 @@
 
 import { fetchFlashcards, sendReviewResults } from "./api";
 import { PartWithAnswers, hasAnswers } from "./blank";
+import { CardKind, getCardKind } from "./cards";
 import { Difficulty, DifficultyTuner } from "./difficulty";
 import { Item } from "./item";
 import { ReviewResult } from "./schema";
@@ -20,8 +21,10 @@ export class ItemBuffer {
   keys: Set<string>;
   difficultyTuner: DifficultyTuner;
   reviews: ReviewResult[];
+  kind: CardKind;
 
-  constructor(difficulty: Difficulty = {}) {
+  constructor(difficulty: Difficulty = {}, kind: CardKind = getCardKind()) {
+    this.kind = kind;
     this.difficultyTuner = new DifficultyTuner(difficulty);
     this.buffer = [];
     this.keys = new Set();
@@ -107,6 +110,7 @@ export class ItemBuffer {
       reviews,
       difficulty: this.difficultyTuner.difficulty,
       exclude: Array.from(this.keys),
+      kind: this.kind,
     });
     items.forEach((item) => this.add(item));
     reviews.forEach((review) => this.keys.delete(review.word));
@@ -0,0 +1,214 @@
+// Card kinds other than cloze.
+// Each kind is scheduled in its own review track on the server.
+
+import "./cards.css";
+import { PartWithAnswers, compare, hasAnswers } from "./blank";
+import { announceResult } from "./buffer";
+import { createButton } from "./button";
+import { gradeAnswer, secondsSince } from "./grade";
+import { getL2 } from "./language";
+import { Sentence } from "./sentence";
+
+export type CardKind = "cloze" | "production" | "choice" | "scramble";
+
+const cardKinds: CardKind[] = ["cloze", "production", "choice", "scramble"];
+
+// Returns card kind selected in the URL (e.g. /study?kind=choice).
+// Defaults to cloze.
+export function getCardKind(): CardKind {
+  const kind = new URLSearchParams(window.location.search).get("kind");
+  return cardKinds.find((k) => k === kind) || "cloze";
+}
+
+function sentenceText(sentence: Sentence): string {
+  return sentence.parts.map((part) => part.text).join("");
+}
+
+function getBlankParts(sentence: Sentence): PartWithAnswers[] {
+  return sentence.parts.filter(hasAnswers) as PartWithAnswers[];
+}
+
+// Notifies item buffer of the result for every word in the card.
+// Results aren't marked as new, so they don't affect difficulty tuning.
+function announceCardResult(
+  kind: CardKind,
+  sentence: Sentence,
+  correct: boolean,
+  start: number
+) {
+  const grade = gradeAnswer({ correct, seconds: secondsSince(start) });
+  for (const part of getBlankParts(sentence)) {
+    const answer = part.answers[0];
+    announceResult({
+      word: answer.normalized,
+      correct,
+      grade,
+      kind,
+      timestamp: Math.floor(Date.now() / 1000),
+    });
+  }
+}
+
+function createSentenceDiv(sentence: Sentence): HTMLDivElement {
+  const div = document.createElement("div");
+  div.classList.add("sentence");
+  div.lang = getL2().bcp47;
+  div.textContent = sentenceText(sentence);
+  return div;
+}
+
+// Multiple choice: pick the missing word.
+function createChoiceBody(
+  sentence: Sentence,
+  choices: string[],
+  done: () => void
+): HTMLDivElement {
+  const start = Date.now();
+  const part = getBlankParts(sentence)[0];
+  const div = document.createElement("div");
+
+  const p = document.createElement("div");
+  p.classList.add("sentence");
+  p.lang = getL2().bcp47;
+  for (const part of sentence.parts) {
+    const span = document.createElement("span");
+    span.textContent = hasAnswers(part) ? "_____" : part.text;
+    p.appendChild(span);
+  }
+
+  const group = document.createElement("div");
+  group.classList.add("button-group", "choices");
+  group.lang = getL2().bcp47;
+
+  const buttons = choices.map((choice) => {
+    const button = createButton(choice, () => {
+      const correct = part != null && choice === part.answers[0].text;
+      button.classList.add(correct ? "correct" : "incorrect");
+      buttons.forEach((b) => {
+        b.disabled = true;
+        if (part != null && b.textContent === part.answers[0].text) {
+          b.classList.add("correct");
+        }
+      });
+      p.replaceWith(createSentenceDiv(sentence));
+      announceCardResult("choice", sentence, correct, start);
+      done();
+    });
+    button.type = "button";
+    return button;
+  });
+  group.append(...buttons);
+  div.append(p, group);
+  return div;
+}
+
+// Production: translate the L1 sentence into L2.
+// Returns a check function for the submit button.
+function createProductionBody(
+  sentence: Sentence,
+  done: () => void,
+  enable: (ok: boolean) => void
+): [HTMLDivElement, () => void] {
+  const start = Date.now();
+  const div = document.createElement("div");
+
+  const input = document.createElement("textarea");
+  input.classList.add("production");
+  input.lang = getL2().bcp47;
+  input.rows = 2;
+  input.addEventListener("input", () => enable(input.value.trim() !== ""));
+
+  let checked = false;
+  const check = () => {
+    if (checked || input.value.trim() === "") {
+      return;
+    }
+    checked = true;
+
+    const correct = compare(input.value, sentenceText(sentence)) === 0;
+    input.classList.add(correct ? "correct" : "incorrect");
+    input.disabled = true;
+
+    // Show expected answer.
+    div.appendChild(createSentenceDiv(sentence));
+    announceCardResult("production", sentence, correct, start);
+    done();
+  };
+  input.addEventListener("keydown", (event: KeyboardEvent) => {
+    if (event.key === "Enter" && !event.shiftKey) {
+      event.preventDefault();
+      check();
+    }
+  });
+
+  div.appendChild(input);
+  return [div, check];
+}
+
+// Scramble: put the words in the correct order.
+function createScrambleBody(
+  sentence: Sentence,
+  scrambled: string[],
+  done: () => void
+): HTMLDivElement {
+  const start = Date.now();
+  const expected = sentence.parts
+    .map((part) => part.text)
+    .filter((text) => text.trim() !== "");
+
+  const div = document.createElement("div");
+  const answer = document.createElement("div");
+  answer.classList.add("sentence", "scramble-answer");
+  answer.lang = getL2().bcp47;
+
+  const group = document.createElement("div");
+  group.classList.add("button-group", "scramble");
+  group.lang = getL2().bcp47;
+
+  const picked: string[] = [];
+  for (const token of scrambled) {
+    const button = createButton(token, () => {
+      button.disabled = true;
+      picked.push(token);
+      answer.textContent = picked.join(" ");
+      if (picked.length < expected.length) {
+        return;
+      }
+
+      const correct = picked.every((token, i) => token === expected[i]);
+      answer.classList.add(correct ? "correct" : "incorrect");
+      if (!correct) {
+        div.appendChild(createSentenceDiv(sentence));
+      }
+      announceCardResult("scramble", sentence, correct, start);
+      done();
+    });
+    button.type = "button";
+    group.appendChild(button);
+  }
+
+  div.append(answer, group);
+  return div;
+}
+
+// Creates body of non-cloze card.
+// Returns the card body and a check function for the submit button.
+export function createCardBody(
+  kind: CardKind,
+  sentence: Sentence,
+  extra: { choices?: string[]; scrambled?: string[] },
+  done: () => void,
+  enable: (ok: boolean) => void
+): [HTMLDivElement, () => void] {
+  switch (kind) {
+    case "choice":
+      return [createChoiceBody(sentence, extra.choices || [], done), () => {}];
+    case "scramble":
+      return [
+        createScrambleBody(sentence, extra.scrambled || [], done),
+        () => {},
+      ];
+    default:
+      return createProductionBody(sentence, done, enable);
+  }
+}
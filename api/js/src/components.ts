@@ -10,6 +10,7 @@ import { setButtonLink } from "./button";
 import { createScoreCounter } from "./counter";
 import { createDiacriticButtonSettingsSection } from "./diacritic";
 import { getL2 } from "./language";
+import { createListeningModeSettingsSection } from "./listening";
 import { createResponsiveMenu } from "./menu";
 import { createOverviewPage } from "./overview";
 import { ActivitySummary, Course, DataPoint } from "./schema";
@@ -142,7 +143,9 @@ export class CourseSettings extends HTMLElement {
     this.append(
       createDiacriticButtonSettingsSection(),
       document.createElement("br"),
-      createVoiceSettingsSection(this.tts)
+      createVoiceSettingsSection(this.tts),
+      document.createElement("br"),
+      createListeningModeSettingsSection()
     );
   }
 }
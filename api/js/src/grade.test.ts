@@ -0,0 +1,31 @@
+import { gradeAnswer, slowAnswerSeconds } from "./grade";
+
+import assert from "assert";
+
+describe("gradeAnswer", () => {
+  describe("incorrect answer", () => {
+    it("should return again", () => {
+      assert.equal(gradeAnswer({ correct: false, seconds: 1 }), "again");
+    });
+  });
+
+  describe("answer with a typo", () => {
+    it("should return hard", () => {
+      const attempt = { correct: true, almost: true, seconds: 1 };
+      assert.equal(gradeAnswer(attempt), "hard");
+    });
+  });
+
+  describe("slow answer", () => {
+    it("should return hard", () => {
+      const attempt = { correct: true, seconds: slowAnswerSeconds + 1 };
+      assert.equal(gradeAnswer(attempt), "hard");
+    });
+  });
+
+  describe("quick correct answer", () => {
+    it("should return good", () => {
+      assert.equal(gradeAnswer({ correct: true, seconds: 1 }), "good");
+    });
+  });
+});
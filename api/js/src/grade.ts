@@ -0,0 +1,31 @@
+// Grades review results.
+
+import { Grade } from "./schema";
+
+// Correct answers that took longer than this many seconds are graded "hard".
+export const slowAnswerSeconds = 20;
+
+export type Attempt = {
+  correct: boolean;
+
+  // True if the answer had a typo or was otherwise almost correct.
+  almost?: boolean;
+
+  // Time it took to answer in seconds.
+  seconds: number;
+};
+
+export function gradeAnswer(attempt: Attempt): Grade {
+  if (!attempt.correct) {
+    return "again";
+  }
+  if (attempt.almost || attempt.seconds > slowAnswerSeconds) {
+    return "hard";
+  }
+  return "good";
+}
+
+// Returns number of seconds since `start` (in milliseconds).
+export function secondsSince(start: number): number {
+  return (Date.now() - start) / 1000;
+}
@@ -1,7 +1,10 @@
 import "./item.css";
+import { WordAction, submitWordAction } from "./api";
 import { createButton } from "./button";
+import { CardKind, createCardBody } from "./cards";
 import { createDiacriticButtonGroup } from "./diacritic";
 import { getL1, getL2 } from "./language";
+import { isListeningMode } from "./listening";
 import { Sentence, createSentence } from "./sentence";
 import { TTS } from "./tts";
 
@@ -13,8 +16,34 @@ export type Translation = {
 export type Item = {
   sentence: Sentence;
   translation: Translation;
+  audio?: string; // URL of sentence audio
+
+  kind?: CardKind; // Cloze if omitted
+  choices?: string[]; // Multiple choice cards
+  scrambled?: string[]; // Word-order scrambles
 };
 
+// Plays sentence audio, or falls back to the browser's TTS.
+export function playItemAudio(tts: TTS, item: Item) {
+  if (item.audio) {
+    tts.stop();
+    new Audio(item.audio).play().catch(() => {
+      // Autoplay may be blocked before the user interacts with the page.
+    });
+    return;
+  }
+  const text = item.sentence.parts.map((part) => part.text).join("");
+  tts.speak(text);
+}
+
+// Shows hidden translation.
+function showTranslation(body: HTMLDivElement) {
+  const p = body.querySelector("p.translation");
+  if (p != null) {
+    (p as HTMLParagraphElement).style.display = "";
+  }
+}
+
 function showTranslationLink(translation: Translation, body: HTMLDivElement) {
   if (translation.tatoebaID == null || translation.tatoebaID <= 0) {
     return;
@@ -54,12 +83,30 @@ function createItemBody(
   enable: (ok: boolean) => void
 ): [HTMLDivElement, () => void, () => void] {
   const div = document.createElement("div");
+  if (item.kind != null && item.kind !== "cloze") {
+    const [body, check] = createCardBody(
+      item.kind,
+      item.sentence,
+      item,
+      done,
+      enable
+    );
+    const translation = createTranslation(item.translation);
+    div.append(translation, body);
+    return [div, check, () => {}];
+  }
+
   const [sentence, check, resize, inputChar] = createSentence(
     item.sentence,
     done,
     enable
   );
-  div.append(sentence, createTranslation(item.translation));
+  const translation = createTranslation(item.translation);
+  if (isListeningMode()) {
+    // The translation would give away the blanks.
+    translation.style.display = "none";
+  }
+  div.append(sentence, translation);
 
   const child = createDiacriticButtonGroup(getL2().code, inputChar);
   if (child != null) {
@@ -68,9 +115,50 @@ function createItemBody(
   return [div, check, resize];
 }
 
-function createItemFooter(submitBtn: HTMLButtonElement): HTMLDivElement {
+// Returns new words that are blanked out in the item.
+function getNewWords(item: Item): string[] {
+  const words: string[] = [];
+  for (const part of item.sentence.parts) {
+    const answer = part.answers?.[0];
+    if (answer != null && answer.new) {
+      words.push(answer.normalized);
+    }
+  }
+  return words;
+}
+
+// Creates buttons for skipping new words the student doesn't want to learn.
+function createSkipButtons(item: Item, next: () => void): HTMLButtonElement[] {
+  const words = getNewWords(item);
+  if (words.length === 0) {
+    return [];
+  }
+
+  const skip = (action: WordAction) => async () => {
+    await Promise.all(words.map((word) => submitWordAction(word, action)));
+    next();
+  };
+  const buttons = [
+    createButton("Known", skip("known")),
+    createButton("Never show", skip("suspend")),
+  ];
+  buttons.forEach((button) => {
+    button.type = "button";
+  });
+  return buttons;
+}
+
+function createItemFooter(
+  submitBtn: HTMLButtonElement,
+  listenBtn?: HTMLButtonElement,
+  skipBtns: HTMLButtonElement[] = []
+): HTMLDivElement {
   const div = document.createElement("div");
   div.classList.add("button-group");
+  div.append(...skipBtns);
+  if (listenBtn != null) {
+    div.appendChild(listenBtn);
+  }
   div.appendChild(submitBtn);
   return div;
 }
@@ -98,18 +186,28 @@ export function createItem(
 ): [HTMLDivElement, () => void] {
   const [submitBtn, enable] = createSubmitButton();
 
+  const skipBtns = createSkipButtons(item, next);
   const done = () => {
-    const text = item.sentence.parts.map((part) => part.text).join("");
-    tts.speak(text);
+    // Too late to skip.
+    skipBtns.forEach((button) => button.remove());
+
+    playItemAudio(tts, item);
 
     hideDiacriticButtonGroup(getBody());
+    showTranslation(getBody());
     showTranslationLink(item.translation, getBody());
     const btn = createButton("Next", next);
     submitBtn.replaceWith(btn);
     btn.focus();
   };
   const [body, check, resize] = createItemBody(item, done, enable);
-  const footer = createItemFooter(submitBtn);
+  const listenBtn = isListeningMode()
+    ? createButton("Listen", () => playItemAudio(tts, item))
+    : undefined;
+  if (listenBtn != null) {
+    listenBtn.type = "button";
+  }
+  const footer = createItemFooter(submitBtn, listenBtn, skipBtns);
 
   submitBtn.addEventListener("click", check);
 
@@ -0,0 +1,32 @@
+// Listening mode: the translation stays hidden and the sentence is played
+// back, so the blanks have to be filled in by ear.
+
+import { getL2 } from "./language";
+
+// Checks if listening mode is enabled for the selected language.
+export function isListeningMode(): boolean {
+  const lang = getL2();
+  return localStorage.getItem(`listening.${lang.code}.enabled`) === "true";
+}
+
+function setListeningMode(enabled: boolean) {
+  const lang = getL2();
+  localStorage.setItem(`listening.${lang.code}.enabled`, String(enabled));
+}
+
+export function createListeningModeSettingsSection(): HTMLFormElement {
+  const form = document.createElement("form");
+  form.classList.add("signin");
+
+  form.innerHTML = `
+    <div>
+      <input type="checkbox" id="enable-listening-mode" name="enable-listening-mode">
+      <label for="enable-listening-mode">Listening mode (hide translations and play sentences aloud)</label>
+    </div>
+  `;
+
+  const input = form.querySelector("input") as HTMLInputElement;
+  input.checked = isListeningMode();
+  input.addEventListener("click", () => setListeningMode(input.checked));
+  return form;
+}
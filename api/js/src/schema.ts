@@ -1,5 +1,6 @@
 // JSON schemas used by server.
 
+import { CardKind } from "./cards";
 import { Difficulty } from "./difficulty";
 import { Item } from "./item";
 
@@ -10,6 +11,14 @@ export type ItemsSchema = {
 export type FlashcardsResponse = {
   items: Item[];
   difficulty: Difficulty;
+
+  // New words and reviews left for the day (negative if unlimited).
+  quota?: Quota;
+};
+
+export type Quota = {
+  newWords: number;
+  reviews: number;
 };
 
 export type SetCourseRequest = {
@@ -46,6 +55,9 @@ export type Word = {
   reviewed: string;
   due: string;
   strength: number;
+  forms?: string[]; // Inflected forms if the word is a lemma
+  state?: "suspended" | "drilling" | "buried" | "known";
+  buriedUntil?: string;
 };
 
 // from /api/vocabulary/<l1>/<l2>
@@ -121,11 +133,20 @@ export type EstimatedLevelSchema = {
   estimatedLevel: DataPointSchema[];
 };
 
+export type Grade = "again" | "hard" | "good" | "easy";
+
 export type ReviewResult = {
   word: string;
   correct: boolean;
   timestamp: number;
 
+  // Optional. The server derives the grade from `correct` if omitted, and
+  // rejects grades that disagree with `correct`.
+  grade?: Grade;
+
+  // Review track of the result. Cloze if omitted.
+  kind?: CardKind;
+
   // This field doesn't need to be sent to the server.
   new?: boolean;
 };
@@ -1,13 +1,14 @@
 import "./sentence.css";
 import {
-  compare,
   createBlank,
   evaluateInput,
   hasAnswers,
+  matchAnswer,
   Part,
   PartWithAnswers,
 } from "./blank";
 import { announceResult } from "./buffer";
+import { gradeAnswer, secondsSince } from "./grade";
 import { getL2 } from "./language";
 
 export type Sentence = {
@@ -37,6 +38,7 @@ export function createSentence(
   done: () => void,
   enable: (ok: boolean) => void
 ): [HTMLDivElement, () => void, () => void, (char: string) => void] {
+  const start = Date.now();
   const resizeFns: Array<() => void> = [];
   const div = document.createElement("div");
   div.classList.add("sentence");
@@ -109,24 +111,27 @@ export function createSentence(
     render();
 
     // Notify buffer of results.
+    const seconds = secondsSince(start);
     for (const [i, input] of inputs.entries()) {
       const answer = input.value;
 
       // Normalize word.
       let word = answer;
       let new_ = false;
-      for (const answer of blankParts[i].answers) {
-        if (compare(input.value, answer.text) === 0) {
-          word = answer.normalized;
-          new_ = answer.new;
-          break;
-        }
+      const match = matchAnswer(input.value, blankParts[i].answers);
+      if (match != null) {
+        word = match[0].normalized;
+        new_ = match[0].new;
       }
 
+      // Status classes accumulate, so "incorrect" means the answer had to be
+      // revealed, and "almost" means there was a typo along the way.
       const correct = !input.classList.contains("incorrect");
+      const almost = input.classList.contains("almost");
       announceResult({
         word,
         correct,
+        grade: gradeAnswer({ correct, almost, seconds }),
         new: new_,
         timestamp: Math.floor(Date.now() / 1000),
       });
@@ -24,8 +24,7 @@ function createHiddenFileInput(
 
   input.addEventListener("change", () => {
     const files = input.files || [];
-    const file = files[0];
-    uploadFile(name, files[0], onError);
+    uploadFile(name, files[0], shouldMerge(input), onError);
   });
   return input;
 }
@@ -98,7 +97,7 @@ export function createFileBrowser(name: string): HTMLDivElement {
 
     // Upload file.
     const file = event.dataTransfer.files[0];
-    uploadFile(name, file, onError);
+    uploadFile(name, file, shouldMerge(div), onError);
   });
   return div;
 
@@ -113,11 +112,19 @@ export function createFileBrowser(name: string): HTMLDivElement {
   }
 }
 
+// Checks if the "merge" checkbox in the element's form is checked.
+function shouldMerge(element: HTMLElement): boolean {
+  const form = element.closest("form");
+  const checkbox = form?.querySelector<HTMLInputElement>('input[name="merge"]');
+  return checkbox != null && checkbox.checked;
+}
+
 // Wrapper around `uploadCSVFile` that checks for file validity and refreshes
 // the page after a successful upload.
 async function uploadFile(
   name: string,
   file: File | undefined,
+  merge: boolean,
   onError: (message: string) => void
 ) {
   if (file == null) {
@@ -132,7 +139,7 @@ async function uploadFile(
     onError("The file is too big.");
     return;
   }
-  const { message, success } = await uploadCSVFile(name, file);
+  const { message, success } = await uploadCSVFile(name, file, { merge });
   if (success) {
     window.location.href = window.location.href;
   }
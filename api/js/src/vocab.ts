@@ -1,5 +1,5 @@
 import "./vocab.css";
-import { fetchVocabulary } from "./api";
+import { WordAction, fetchVocabulary, submitWordAction } from "./api";
 import { createButton } from "./button";
 import { createDateTime } from "./datetime";
 import { getL2 } from "./language";
@@ -23,13 +23,68 @@ function createStrengthMeter(strength: number): HTMLMeterElement {
   return meter;
 }
 
+// Checks if the page only lists leeches (/vocab?filter=leech).
+function isLeechFilter(): boolean {
+  const params = new URLSearchParams(window.location.search);
+  return params.get("filter") === "leech";
+}
+
 function createVocabularyListHeader(): HTMLHeadingElement {
   const h1 = document.createElement("h1");
   const l2 = getL2();
-  h1.textContent = `${l2.name} vocabulary`;
+  h1.textContent = isLeechFilter()
+    ? `${l2.name} leeches`
+    : `${l2.name} vocabulary`;
   return h1;
 }
 
+// Status text shown after an action succeeds.
+const actionStatus: Record<WordAction, string> = {
+  suspend: "suspended",
+  unsuspend: "",
+  drill: "drilling",
+  reset: "reset",
+  bury: "buried",
+  unbury: "",
+  known: "known",
+};
+
+// Creates buttons for changing the state of a word.
+// Leeches can also be reset or drilled.
+function createWordActions(word: Word, leech: boolean): HTMLDivElement {
+  const div = document.createElement("div");
+  div.classList.add("button-group");
+
+  const status = document.createElement("span");
+  status.textContent = word.state || "";
+
+  const add = (label: string, action: WordAction) => {
+    const button = createButton(label, async () => {
+      if (await submitWordAction(word.word, action)) {
+        status.textContent = actionStatus[action];
+      }
+    });
+    div.appendChild(button);
+  };
+  if (word.state === "suspended") {
+    add("Unsuspend", "unsuspend");
+  } else {
+    add("Suspend", "suspend");
+  }
+  if (word.state === "buried") {
+    add("Unbury", "unbury");
+  } else {
+    add("Bury", "bury");
+  }
+  add("Known", "known");
+  if (leech) {
+    add("Reset", "reset");
+    add("Keep drilling", "drill");
+  }
+  div.appendChild(status);
+  return div;
+}
+
 function createVocabularyListTableRow(
   word: Word,
   tts?: TTS
@@ -39,10 +94,21 @@ function createVocabularyListTableRow(
   const due = new Date(Date.parse(word.due));
 
   const td = createTableData(word.word);
+  if (word.forms != null && word.forms.length > 0) {
+    // Show the lemma family.
+    td.title = word.forms.join(", ");
+    const small = document.createElement("small");
+    small.textContent = ` (${word.forms.join(", ")})`;
+    td.appendChild(small);
+  }
   if (tts) {
     td.addEventListener("click", () => tts.speak(word.word));
   }
 
+  if (word.state === "suspended" || word.state === "buried") {
+    td.classList.add("suspended");
+  }
+
   const tr = document.createElement("tr");
   tr.append(
     td,
@@ -51,6 +117,7 @@ function createVocabularyListTableRow(
     createTableData(createDateTime(reviewed)),
     createTableData(createDateTime(due))
   );
+  tr.appendChild(createTableData(createWordActions(word, isLeechFilter())));
   return tr;
 }
 
@@ -72,7 +139,14 @@ function createVocabularyListTableBody(
 function createVocabularyListBody(
   tts?: TTS
 ): [HTMLDivElement, (words: Word[]) => void] {
-  const headers = ["Word", "Strength", "Learned", "Last seen", "Due"];
+  const headers = [
+    "Word",
+    "Strength",
+    "Learned",
+    "Last seen",
+    "Due",
+    "Actions",
+  ];
   const [body, update] = createVocabularyListTableBody(tts);
   const table = createTable(createTableHeader(headers), body);
   return [createScrollingTable(table), update];
@@ -112,7 +186,11 @@ export async function createVocabularyList(tts?: TTS): Promise<HTMLDivElement> {
   return div;
 
   async function loadMore() {
-    const items = await fetchVocabulary({ after, limit: 100 });
+    const items = await fetchVocabulary({
+      after,
+      limit: 100,
+      filter: isLeechFilter() ? "leech" : undefined,
+    });
     const ok = items.length > 0 && items[items.length - 1].word !== after;
     if (!ok) {
       button.remove();
@@ -0,0 +1,137 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Leech handling.
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Bounds of the leech threshold setting.
+const (
+	minLeechThreshold = 2
+	maxLeechThreshold = 100
+)
+
+var errInvalidLeechThreshold = errors.New("invalid leech threshold")
+
+// Gets user's leech threshold for the course.
+// Returns the default threshold if the user hasn't set one.
+func getLeechThreshold(db *sql.DB, l1, l2 string) (int, error) {
+	value, err := getCourseSetting(db, l1, l2, "leech-threshold")
+	if err != nil {
+		return 0, fmt.Errorf("failed to get leech threshold: %w", err)
+	}
+	threshold, err := strconv.Atoi(value)
+	if err != nil || threshold < minLeechThreshold {
+		return rs.DefaultLeechThreshold, nil
+	}
+	return threshold, nil
+}
+
+// Gets user's leech threshold for the course from the user DB.
+func getUserLeechThreshold(userID int, l1, l2 string) (int, error) {
+	db, err := database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get leech threshold: %w", err)
+	}
+	defer db.Close()
+	return getLeechThreshold(db, l1, l2)
+}
+
+// Sets user's leech threshold for the course.
+func setLeechThreshold(db *sql.DB, l1, l2 string, threshold int) error {
+	if threshold < minLeechThreshold || threshold > maxLeechThreshold {
+		return errInvalidLeechThreshold
+	}
+	value := strconv.Itoa(threshold)
+	if err := setCourseSetting(db, l1, l2, "leech-threshold", value); err != nil {
+		return fmt.Errorf("failed to set leech threshold: %w", err)
+	}
+	return nil
+}
+
+func handleSetLeechThreshold(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	csrfToken := r.FormValue("csrf-token")
+	threshold, err := strconv.Atoi(r.FormValue("leech-threshold"))
+
+	// Check CSRF token.
+	if !s.CheckCSRFToken(csrfToken) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"leeches",
+		)
+		goto fail
+	}
+
+	if err != nil || threshold < minLeechThreshold || threshold > maxLeechThreshold {
+		_ = s.ErrorMessage(
+			fmt.Sprintf(
+				"Leech threshold should be between %v and %v.",
+				minLeechThreshold,
+				maxLeechThreshold,
+			),
+			"leeches",
+		)
+		goto fail
+	}
+
+	db, err = database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"leeches",
+		)
+		goto fail
+	}
+	defer db.Close()
+
+	if err := setLeechThreshold(db, l1, l2, threshold); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"leeches",
+		)
+		goto fail
+	}
+	_ = s.SuccessMessage("Leech threshold updated.", "leeches")
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Lemma mode: schedules lemmas instead of surface forms.
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Checks if lemma mode is enabled in the user DB.
+func isLemmaMode(db *sql.DB, l1, l2 string) (bool, error) {
+	value, err := getCourseSetting(db, l1, l2, "lemma")
+	if err != nil {
+		return false, fmt.Errorf("failed to get lemma mode: %w", err)
+	}
+	return value == "on", nil
+}
+
+func handleSetLemmaMode(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	csrfToken := r.FormValue("csrf-token")
+
+	value := ""
+	if r.FormValue("lemma") == "on" {
+		value = "on"
+	}
+
+	// Check CSRF token.
+	if !s.CheckCSRFToken(csrfToken) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"lemma",
+		)
+		goto fail
+	}
+
+	db, err = database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"lemma",
+		)
+		goto fail
+	}
+	defer db.Close()
+
+	if err := setCourseSetting(db, l1, l2, "lemma", value); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"lemma",
+		)
+		goto fail
+	}
+
+	// Prefetched flashcards are for surface forms.
+	invalidateFlashcards(r, userID, l1, l2)
+	_ = s.SuccessMessage("Lemma mode updated.", "lemma")
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
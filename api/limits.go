@@ -0,0 +1,249 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Daily new-word and review limits.
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // So that timezones work on systems without zoneinfo
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/sessions"
+	"github.com/polycloze/polycloze/word_scheduler"
+)
+
+// Upper bound of daily limits.
+// Zero means there's no limit.
+const maxDailyLimit = 10000
+
+// Default start of the study day (4 AM), so that late-night reviews count
+// towards the previous day.
+const defaultRolloverHour = 4
+
+var errInvalidDailyLimits = errors.New("invalid daily limits")
+
+// User's daily limits for a course.
+type dailyLimits struct {
+	word_scheduler.Limits
+
+	// Hour of the day when the study day starts.
+	RolloverHour int
+
+	// IANA timezone name (e.g. "Asia/Manila").
+	// Empty means UTC.
+	Timezone string
+}
+
+// Returns the start of the study day that contains now.
+func (l dailyLimits) dayStart(now time.Time) time.Time {
+	loc, err := time.LoadLocation(l.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return word_scheduler.DayStart(now, loc, l.RolloverHour)
+}
+
+// Gets integer course setting.
+// Returns fallback if the setting hasn't been set or is invalid.
+func getIntCourseSetting(db *sql.DB, l1, l2, name string, fallback int) (int, error) {
+	value, err := getCourseSetting(db, l1, l2, name)
+	if err != nil {
+		return 0, err
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback, nil
+	}
+	return n, nil
+}
+
+// Gets user's daily limits for the course.
+// The timezone setting is shared by all courses.
+func getDailyLimits(db *sql.DB, l1, l2 string) (dailyLimits, error) {
+	var limits dailyLimits
+	var err error
+
+	limits.NewWords, err = getIntCourseSetting(db, l1, l2, "new-words-per-day", 0)
+	if err != nil {
+		return limits, fmt.Errorf("failed to get daily limits: %w", err)
+	}
+	limits.Reviews, err = getIntCourseSetting(db, l1, l2, "reviews-per-day", 0)
+	if err != nil {
+		return limits, fmt.Errorf("failed to get daily limits: %w", err)
+	}
+	limits.RolloverHour, err = getIntCourseSetting(db, l1, l2, "day-rollover-hour", defaultRolloverHour)
+	if err != nil {
+		return limits, fmt.Errorf("failed to get daily limits: %w", err)
+	}
+	if limits.RolloverHour < 0 || limits.RolloverHour > 23 {
+		limits.RolloverHour = defaultRolloverHour
+	}
+	limits.Timezone, err = getUserSetting(db, "timezone")
+	if err != nil {
+		return limits, fmt.Errorf("failed to get daily limits: %w", err)
+	}
+	return limits, nil
+}
+
+// Checks if daily limits are valid.
+func validateDailyLimits(limits dailyLimits) error {
+	if limits.NewWords < 0 || limits.NewWords > maxDailyLimit {
+		return errInvalidDailyLimits
+	}
+	if limits.Reviews < 0 || limits.Reviews > maxDailyLimit {
+		return errInvalidDailyLimits
+	}
+	if limits.RolloverHour < 0 || limits.RolloverHour > 23 {
+		return errInvalidDailyLimits
+	}
+	// "Local" would be the server's timezone.
+	if _, err := time.LoadLocation(limits.Timezone); err != nil || limits.Timezone == "Local" {
+		return errInvalidDailyLimits
+	}
+	return nil
+}
+
+// Sets user's daily limits for the course.
+func setDailyLimits(db *sql.DB, l1, l2 string, limits dailyLimits) error {
+	if err := validateDailyLimits(limits); err != nil {
+		return err
+	}
+
+	settings := map[string]int{
+		"new-words-per-day": limits.NewWords,
+		"reviews-per-day":   limits.Reviews,
+		"day-rollover-hour": limits.RolloverHour,
+	}
+	for name, value := range settings {
+		if err := setCourseSetting(db, l1, l2, name, strconv.Itoa(value)); err != nil {
+			return fmt.Errorf("failed to set daily limits: %w", err)
+		}
+	}
+	if err := setUserSetting(db, "timezone", limits.Timezone); err != nil {
+		return fmt.Errorf("failed to set daily limits: %w", err)
+	}
+	return nil
+}
+
+// Computes user's remaining quota for today.
+// Outstanding words that the client hasn't answered yet use up quota too, so
+// that clients can't go past the limits by holding on to flashcards.
+// db is the user DB, and q should have access to the user's review data.
+func getQuota[T database.Querier](
+	db *sql.DB,
+	l1, l2 string,
+	q T,
+	outstanding []string,
+	now time.Time,
+) (word_scheduler.Quota, error) {
+	limits, err := getDailyLimits(db, l1, l2)
+	if err != nil {
+		return word_scheduler.Unlimited, err
+	}
+	quota, err := word_scheduler.GetQuota(q, limits.Limits, limits.dayStart(now))
+	if err != nil || quota == word_scheduler.Unlimited {
+		return quota, err
+	}
+
+	newWords, reviews, err := word_scheduler.CountOutstanding(q, outstanding, now)
+	if err != nil {
+		return quota, fmt.Errorf("failed to get quota: %w", err)
+	}
+	return quota.Minus(newWords, reviews), nil
+}
+
+func handleSetLimits(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	csrfToken := r.FormValue("csrf-token")
+
+	var limits dailyLimits
+	var invalid bool
+	for name, value := range map[string]*int{
+		"new-words-per-day": &limits.NewWords,
+		"reviews-per-day":   &limits.Reviews,
+		"day-rollover-hour": &limits.RolloverHour,
+	} {
+		n, err := strconv.Atoi(r.FormValue(name))
+		if err != nil {
+			invalid = true
+		}
+		*value = n
+	}
+	limits.Timezone = strings.TrimSpace(r.FormValue("timezone"))
+
+	// Check CSRF token.
+	if !s.CheckCSRFToken(csrfToken) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"limits",
+		)
+		goto fail
+	}
+
+	if invalid || validateDailyLimits(limits) != nil {
+		_ = s.ErrorMessage(
+			fmt.Sprintf(
+				"Limits should be between 0 and %v, the hour between 0 and 23, and the timezone a valid name (e.g. Asia/Manila).",
+				maxDailyLimit,
+			),
+			"limits",
+		)
+		goto fail
+	}
+
+	db, err = database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"limits",
+		)
+		goto fail
+	}
+	defer db.Close()
+
+	if err := setDailyLimits(db, l1, l2, limits); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"limits",
+		)
+		goto fail
+	}
+	_ = s.SuccessMessage("Daily limits updated.", "limits")
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
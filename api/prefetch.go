@@ -0,0 +1,60 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Access to prefetched flashcards.
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/flashcards"
+)
+
+// Gets flashcard prefetcher from request context.
+// Returns nil if the router wasn't configured with a prefetcher.
+func getPrefetcher(r *http.Request) *flashcards.Prefetcher {
+	return getConfig(r).Prefetcher
+}
+
+// Takes up to n prefetched flashcards for the user.
+func takeFlashcards(
+	r *http.Request,
+	userID int,
+	l1, l2 string,
+	n int,
+	pred func(word string) bool,
+) []flashcards.Item {
+	if prefetcher := getPrefetcher(r); prefetcher != nil {
+		return prefetcher.Take(basedir.Review(userID, l1, l2), n, pred)
+	}
+
+	// To make sure JSON encoding is not nil:
+	return make([]flashcards.Item, 0)
+}
+
+// Prefetches flashcards for the user's next request in the background.
+// exclude: words that the client already has
+func prefetchFlashcards(r *http.Request, userID int, l1, l2 string, exclude []string) {
+	prefetcher := getPrefetcher(r)
+	if prefetcher == nil {
+		return
+	}
+
+	// Request context shouldn't be used after the handler returns, so the
+	// opener only gets the registry.
+	registry := getRegistry(r)
+	opener := func() (*sql.DB, func(), error) {
+		return openReviewDBWith(registry, userID, l1, l2)
+	}
+	prefetcher.Refill(basedir.Review(userID, l1, l2), exclude, opener)
+}
+
+// Discards prefetched flashcards for the user.
+// Should be called when the user's review schedule changes.
+func invalidateFlashcards(r *http.Request, userID int, l1, l2 string) {
+	if prefetcher := getPrefetcher(r); prefetcher != nil {
+		prefetcher.Invalidate(basedir.Review(userID, l1, l2))
+	}
+}
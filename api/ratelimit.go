@@ -0,0 +1,128 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Per-client rate limits.
+package api
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token bucket of a client.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type rateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Tokens per second
+	burst   float64
+	clients map[string]*bucket
+	pruned  time.Time
+}
+
+func newRateLimiter(perMinute, burst int) *rateLimiter {
+	if burst <= 0 {
+		burst = 1
+	}
+	return &rateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(burst),
+		clients: make(map[string]*bucket),
+	}
+}
+
+// Checks if the client can make a request now, and takes a token if it can.
+func (l *rateLimiter) allow(client string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.pruned) > time.Minute {
+		l.prune(now)
+	}
+
+	b, ok := l.clients[client]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[client] = b
+	}
+
+	b.tokens += now.Sub(b.last).Seconds() * l.rate
+	if b.tokens > l.burst {
+		b.tokens = l.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Forgets clients whose buckets are full again.
+// Caller should hold the lock.
+func (l *rateLimiter) prune(now time.Time) {
+	for client, b := range l.clients {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+	l.pruned = now
+}
+
+// Returns IP address of the client.
+// If header is set, it's assumed to be set by a trusted reverse proxy (e.g.
+// Fly-Client-IP). Otherwise the X-Forwarded-For header is used if trustProxy
+// is set.
+// Only the last X-Forwarded-For entry is used, because it's the one appended
+// by the proxy. Clients can put anything in the entries before it.
+func clientIP(r *http.Request, trustProxy bool, header string) string {
+	if header != "" {
+		if ip := net.ParseIP(strings.TrimSpace(r.Header.Get(header))); ip != nil {
+			return ip.String()
+		}
+	} else if trustProxy {
+		values := r.Header.Values("X-Forwarded-For")
+		if len(values) > 0 {
+			entries := strings.Split(values[len(values)-1], ",")
+			last := strings.TrimSpace(entries[len(entries)-1])
+			if ip := net.ParseIP(last); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Returns IP address of the client using the config's proxy settings.
+func (c *Config) clientIP(r *http.Request) string {
+	return clientIP(r, c.TrustProxy, c.ClientIPHeader)
+}
+
+// Limits requests to the API and form submissions.
+// Static files aren't rate limited.
+func rateLimitMiddleware(perMinute, burst int, trustProxy bool, header string) func(http.Handler) http.Handler {
+	limiter := newRateLimiter(perMinute, burst)
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			limited := r.Method == "POST" || strings.HasPrefix(r.URL.Path, "/api/")
+			if limited && !limiter.allow(clientIP(r, trustProxy, header), time.Now()) {
+				w.Header().Set("Retry-After", "60")
+				http.Error(w, "Too many requests.", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
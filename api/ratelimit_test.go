@@ -0,0 +1,57 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	limiter := newRateLimiter(60, 2)
+	now := time.Now()
+
+	if !limiter.allow("a", now) || !limiter.allow("a", now) {
+		t.Fatal("expected burst to be allowed")
+	}
+	if limiter.allow("a", now) {
+		t.Fatal("expected request over the burst to be denied")
+	}
+	if !limiter.allow("b", now) {
+		t.Fatal("expected other clients to have their own limits")
+	}
+	if !limiter.allow("a", now.Add(time.Second)) {
+		t.Fatal("expected bucket to refill over time")
+	}
+}
+
+func TestClientIPSpoofedForwardedFor(t *testing.T) {
+	// Clients can prepend entries to X-Forwarded-For, so only the entry
+	// appended by the proxy should be trusted.
+	t.Parallel()
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Add("X-Forwarded-For", "1.2.3.4, 5.6.7.8")
+
+	if ip := clientIP(r, false, ""); ip != "10.0.0.1" {
+		t.Fatal("expected header to be ignored without a trusted proxy:", ip)
+	}
+	if ip := clientIP(r, true, ""); ip != "5.6.7.8" {
+		t.Fatal("expected last entry to be used:", ip)
+	}
+
+	r.Header.Set("Fly-Client-IP", "9.9.9.9")
+	if ip := clientIP(r, true, "Fly-Client-IP"); ip != "9.9.9.9" {
+		t.Fatal("expected configured header to be used:", ip)
+	}
+
+	r.Header.Set("Fly-Client-IP", "not an IP")
+	if ip := clientIP(r, true, "Fly-Client-IP"); ip != "10.0.0.1" {
+		t.Fatal("expected invalid header to be ignored:", ip)
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Access to pooled review DB handles.
+package api
+
+import (
+	"database/sql"
+	"net/http"
+
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+)
+
+// Gets DB registry from request context.
+// Returns nil if the router wasn't configured with a registry.
+func getRegistry(r *http.Request) *database.Registry {
+	return getConfig(r).Registry
+}
+
+// Returns user's review DB with the course DB attached as `course`.
+// The user's custom sentence DB is also attached as `custom` if it exists.
+// The caller must call release after using the DB instead of closing it.
+func openReviewDB(r *http.Request, userID int, l1, l2 string) (*sql.DB, func(), error) {
+	return openReviewDBWith(getRegistry(r), userID, l1, l2)
+}
+
+// Like openReviewDB, but takes the registry directly.
+// Opens an unpooled handle if registry is nil.
+func openReviewDBWith(
+	registry *database.Registry,
+	userID int,
+	l1, l2 string,
+) (*sql.DB, func(), error) {
+	path := basedir.Review(userID, l1, l2)
+	attachments := []database.Attachment{
+		{Name: "course", Path: basedir.Course(l1, l2)},
+	}
+	if hasCustomDB(userID, l1, l2) {
+		attachments = append(attachments, database.Attachment{
+			Name: "custom",
+			Path: basedir.Custom(userID, l1, l2),
+		})
+	}
+
+	if registry != nil {
+		return registry.Acquire(path, attachments...)
+	}
+
+	// Fallback for routers without a registry (e.g. in tests).
+	temp := database.NewRegistry(1, 0)
+	db, release, err := temp.Acquire(path, attachments...)
+	if err != nil {
+		temp.Close()
+		return nil, nil, err
+	}
+	return db, func() {
+		release()
+		temp.Close()
+	}, nil
+}
+
+// Evicts pooled handles to the user's review DB.
+// Should be called before deleting the review DB.
+func invalidateReviewDB(r *http.Request, userID int, l1, l2 string) {
+	if registry := getRegistry(r); registry != nil {
+		registry.Invalidate(basedir.Review(userID, l1, l2))
+	}
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Password reset links issued by the server admin.
+package api
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/mailer"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Returns link to the password reset page.
+// The link is built from the configured public URL, because the Host header
+// is controlled by the client.
+func resetURL(r *http.Request, token string) string {
+	base := strings.TrimSuffix(getConfig(r).PublicURL, "/")
+	return base + "/reset?token=" + url.QueryEscape(token)
+}
+
+// Checks the request's X-Admin-Token header.
+// Always false if the server doesn't have an admin token.
+func isAdmin(r *http.Request) bool {
+	secret := getConfig(r).AdminToken
+	if secret == "" {
+		return false
+	}
+	// Hashes have the same length, so the comparison doesn't leak the
+	// secret's length.
+	expected := sha256.Sum256([]byte(secret))
+	actual := sha256.Sum256([]byte(r.Header.Get("X-Admin-Token")))
+	return subtle.ConstantTimeCompare(expected[:], actual[:]) == 1
+}
+
+type ResetLinkResponse struct {
+	URL     string    `json:"url"`
+	Expires time.Time `json:"expires"`
+	Sent    bool      `json:"sent"` // Whether the link was emailed
+}
+
+// Creates a password reset link for the user.
+// Emails the link if an email address is given.
+func handleCreateResetLink(w http.ResponseWriter, r *http.Request) {
+	if !isAdmin(r) {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	username := r.FormValue("username")
+	email := r.FormValue("email")
+	ttl := auth.DefaultResetTokenAge
+	if value := r.FormValue("expires"); value != "" {
+		var err error
+		ttl, err = time.ParseDuration(value)
+		if err != nil || ttl <= 0 || ttl > auth.MaxResetTokenAge {
+			http.Error(w, "invalid expiry", http.StatusBadRequest)
+			return
+		}
+	}
+
+	if getConfig(r).PublicURL == "" {
+		http.Error(w, "publicURL isn't configured on this server", http.StatusBadRequest)
+		return
+	}
+
+	mail := getConfig(r).Mailer
+	if email != "" && mail == nil {
+		http.Error(w, "mail isn't configured on this server", http.StatusBadRequest)
+		return
+	}
+
+	db := auth.GetDB(r)
+	token, err := auth.CreateResetToken(db, username, ttl)
+	if err != nil {
+		if errors.Is(err, auth.ErrUnknownUser) {
+			http.Error(w, "user not found", http.StatusNotFound)
+			return
+		}
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	response := ResetLinkResponse{
+		URL:     resetURL(r, token),
+		Expires: time.Now().Add(ttl).UTC(),
+	}
+	if email != "" {
+		message := mailer.Message{
+			To:      email,
+			Subject: "Reset your polycloze password",
+			Body: fmt.Sprintf(
+				"Hi %v,\n\nUse this link to set a new password for your polycloze account:\n\n%v\n\nThe link expires on %v and can only be used once.\n",
+				username,
+				response.URL,
+				response.Expires.Format(time.RFC1123),
+			),
+		}
+		if err := mail.Send(message); err != nil {
+			log.Println(err)
+			http.Error(w, "failed to send mail", http.StatusBadGateway)
+			return
+		}
+		response.Sent = true
+	}
+	sendJSON(w, response)
+}
+
+// Password reset page.
+// Consumes the reset token in the link, signs out all of the user's sessions
+// and deletes their access tokens.
+func handleResetPassword(w http.ResponseWriter, r *http.Request) {
+	db := auth.GetDB(r)
+	s, err := sessions.StartOrResumeSession(db, w, r)
+	if err != nil {
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	// Don't leak the token to other sites.
+	w.Header().Set("Referrer-Policy", "no-referrer")
+
+	token := r.FormValue("token")
+	username, err := auth.CheckResetToken(db, token)
+	if err != nil {
+		if !auth.IsInvalidResetToken(err) {
+			log.Println(err)
+		}
+		renderTemplate(w, "reset.html", map[string]any{"invalid": true})
+		return
+	}
+
+	if r.Method == "POST" {
+		password := r.FormValue("password")
+		csrfToken := r.FormValue("csrf-token")
+
+		if !s.CheckCSRFToken(csrfToken) {
+			_ = s.ErrorMessage("Something went wrong. Please try again.", "reset")
+			goto fail
+		}
+		if message := getConfig(r).checkPassword(password); message != "" {
+			_ = s.ErrorMessage(message, "reset")
+			goto fail
+		}
+
+		if _, err := auth.ResetPassword(db, token, password); err != nil {
+			if auth.IsInvalidResetToken(err) {
+				renderTemplate(w, "reset.html", map[string]any{"invalid": true})
+				return
+			}
+			log.Println(err)
+			_ = s.ErrorMessage("Something went wrong. Please try again.", "reset")
+			goto fail
+		}
+
+		// The visitor's session might have been one of the revoked sessions.
+		s, err = sessions.StartOrResumeSession(db, w, r)
+		if err == nil {
+			_ = s.SuccessMessage("Password updated. Sign in with your new password.", "sign-in")
+		}
+		http.Redirect(w, r, "/signin", http.StatusSeeOther)
+		return
+	}
+
+fail:
+	messages, _ := s.Messages("reset")
+	renderTemplate(w, "reset.html", map[string]any{
+		"csrfToken": sessions.CSRFToken(s.ID),
+		"messages":  messages,
+		"token":     token,
+		"account":   username,
+	})
+}
@@ -0,0 +1,252 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/mailer"
+)
+
+// Creates server with password reset routes for testing.
+func testResetServer(db *sql.DB, config Config) *httptest.Server {
+	r := chi.NewRouter()
+	r.Use(auth.Middleware(db))
+	r.Use(auth.BearerMiddleware(db))
+	r.Use(configMiddleware(config))
+	r.HandleFunc("/reset", handleResetPassword)
+	r.HandleFunc("/api/admin/reset-links", handleCreateResetLink)
+	return httptest.NewServer(r)
+}
+
+func TestCreateResetLinkRequiresAdminToken(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	if err := auth.Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	for _, config := range []Config{{}, {AdminToken: "0123456789abcdef"}} {
+		ts := testResetServer(db, config)
+		defer ts.Close()
+
+		v := url.Values{}
+		v.Set("username", "foo")
+		req, err := http.NewRequest("POST", resolve(ts, "/api/admin/reset-links"), strings.NewReader(v.Encode()))
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Admin-Token", "wrong")
+
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatal("expected admin endpoint to be hidden:", resp.StatusCode)
+		}
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	if err := auth.Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	id, err := auth.Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	query := `INSERT INTO user_session (session_id, user_id, username) VALUES ('old', ?, 'foo')`
+	if _, err := db.Exec(query, id); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	accessToken, err := auth.CreateAccessToken(db, id, "test")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "mail")
+	config := Config{
+		AdminToken: "0123456789abcdef",
+		PublicURL:  "https://example.com/",
+		Mailer:     mailer.FileMailer{Dir: dir, From: "noreply@example.com"},
+	}
+	ts := testResetServer(db, config)
+	defer ts.Close()
+
+	// Returns the status code of a request authenticated with the access
+	// token.
+	// Any route behind the bearer middleware will do.
+	bearerStatus := func() int {
+		req, err := http.NewRequest("GET", resolve(ts, "/reset"), nil)
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	if status := bearerStatus(); status != http.StatusOK {
+		t.Fatal("expected access token to work before reset:", status)
+	}
+
+	// Admin creates reset link.
+	v := url.Values{}
+	v.Set("username", "foo")
+	v.Set("email", "foo@example.com")
+	req, err := http.NewRequest("POST", resolve(ts, "/api/admin/reset-links"), strings.NewReader(v.Encode()))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Admin-Token", config.AdminToken)
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer resp.Body.Close()
+
+	var link ResetLinkResponse
+	if err := json.NewDecoder(resp.Body).Decode(&link); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if !link.Sent || !strings.HasPrefix(link.URL, "https://example.com/reset?token=") {
+		t.Fatal("unexpected reset link:", link)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.eml")); len(matches) != 1 {
+		t.Fatal("expected reset link to be mailed:", matches)
+	}
+
+	// User opens the link.
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	tc := ts.Client()
+	tc.Jar = jar
+	path := strings.TrimPrefix(link.URL, "https://example.com")
+	resp, err = tc.Get(resolve(ts, path))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	csrfToken, _ := doc.Find(`input[name="csrf-token"]`).Attr("value")
+	token, _ := doc.Find(`input[name="token"]`).Attr("value")
+
+	v = url.Values{}
+	v.Set("csrf-token", csrfToken)
+	v.Set("token", token)
+	v.Set("password", "new password")
+	tc.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err = tc.PostForm(resolve(ts, "/reset"), v)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusSeeOther || resp.Header.Get("Location") != "/signin" {
+		t.Fatal("expected redirect to sign-in page:", resp.StatusCode)
+	}
+
+	if _, err := auth.Authenticate(db, "foo", "new password"); err != nil {
+		t.Fatal("expected password to be changed:", err)
+	}
+
+	var count int
+	query = `SELECT count(*) FROM user_session WHERE user_id = ?`
+	if err := db.QueryRow(query, id).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 0 {
+		t.Fatal("expected user's sessions to be revoked:", count)
+	}
+	if status := bearerStatus(); status != http.StatusUnauthorized {
+		t.Fatal("expected access token to stop working after reset:", status)
+	}
+
+	// The link can only be used once.
+	resp, err = tc.Get(resolve(ts, path))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	doc, err = goquery.NewDocumentFromReader(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if doc.Find(`input[name="token"]`).Length() != 0 {
+		t.Fatal("expected used link to be invalid")
+	}
+}
+
+func TestCreateResetLinkRequiresPublicURL(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	if err := auth.Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	config := Config{AdminToken: "0123456789abcdef"}
+	ts := testResetServer(db, config)
+	defer ts.Close()
+
+	v := url.Values{}
+	v.Set("username", "foo")
+	req, err := http.NewRequest("POST", resolve(ts, "/api/admin/reset-links"), strings.NewReader(v.Encode()))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Admin-Token", config.AdminToken)
+	// The link mustn't be built from the Host header.
+	req.Host = "attacker.example.com"
+
+	resp, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatal("expected link creation to be refused:", resp.StatusCode)
+	}
+
+	var count int
+	query := `SELECT count(*) FROM password_reset_token`
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 0 {
+		t.Fatal("expected no reset token to be created:", count)
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Review scheduler selection.
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Gets the name of the user's review scheduler for the course.
+// Returns the default scheduler's name if the user hasn't picked one.
+func getSchedulerName(db *sql.DB, l1, l2 string) (string, error) {
+	name, err := getCourseSetting(db, l1, l2, "scheduler")
+	if err != nil {
+		return "", fmt.Errorf("failed to get scheduler: %w", err)
+	}
+	if name == "" {
+		return rs.DefaultSchedulerName, nil
+	}
+	return name, nil
+}
+
+// Gets user's review scheduler for the course.
+func getUserScheduler(userID int, l1, l2 string) (rs.Scheduler, error) {
+	db, err := database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler: %w", err)
+	}
+	defer db.Close()
+	return getScheduler(db, l1, l2)
+}
+
+// Like getUserScheduler, but takes the user DB directly.
+func getScheduler(db *sql.DB, l1, l2 string) (rs.Scheduler, error) {
+	name, err := getSchedulerName(db, l1, l2)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := rs.Lookup(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler: %w", err)
+	}
+
+	threshold, err := getLeechThreshold(db, l1, l2)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduler: %w", err)
+	}
+	return rs.LeechScheduler{Scheduler: s, Threshold: threshold}, nil
+}
+
+// Sets user's review scheduler for the course.
+func setUserScheduler(db *sql.DB, l1, l2, name string) error {
+	if _, err := rs.Lookup(name); err != nil {
+		return fmt.Errorf("failed to set scheduler: %w", err)
+	}
+	if err := setCourseSetting(db, l1, l2, "scheduler", name); err != nil {
+		return fmt.Errorf("failed to set scheduler: %w", err)
+	}
+	return nil
+}
+
+func handleSetScheduler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	csrfToken := r.FormValue("csrf-token")
+	name := r.FormValue("scheduler")
+	var userDB *sql.DB
+
+	// Check CSRF token.
+	if !s.CheckCSRFToken(csrfToken) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"scheduler",
+		)
+		goto fail
+	}
+
+	if _, err := rs.Lookup(name); err != nil {
+		_ = s.ErrorMessage("Unknown scheduler.", "scheduler")
+		goto fail
+	}
+
+	userDB, err = database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"scheduler",
+		)
+		goto fail
+	}
+	defer userDB.Close()
+
+	if err := setUserScheduler(userDB, l1, l2, name); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"scheduler",
+		)
+		goto fail
+	}
+
+	invalidateFlashcards(r, userID, l1, l2)
+	_ = s.SuccessMessage("Scheduler updated.", "scheduler")
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
@@ -5,9 +5,11 @@
 package api
 
 import (
+	"github.com/polycloze/polycloze/custom"
 	"github.com/polycloze/polycloze/difficulty"
 	"github.com/polycloze/polycloze/flashcards"
 	"github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/word_scheduler"
 )
 
 type ReviewResult = review_scheduler.Result
@@ -19,6 +21,15 @@ type FlashcardsRequest struct {
 	Reviews    []ReviewResult         `json:"reviews"`
 	Exclude    []string               `json:"exclude"`
 
+	// Blank multiple words per sentence if true.
+	// Limit is the max number of words instead of flashcards in this mode.
+	// Ignored in lemma mode.
+	Multi bool `json:"multi,omitempty"`
+
+	// Card kind: "cloze" (default), "production", "choice" or "scramble".
+	// Multi mode only supports cloze cards.
+	Kind string `json:"kind,omitempty"`
+
 	// Sometimes used by client if for some reason they can't pass the token via
 	// HTTP headers (e.g. `sendBeacon`).
 	CSRFToken string `json:"csrfToken"`
@@ -28,6 +39,11 @@ type FlashcardsRequest struct {
 type FlashcardsResponse struct {
 	Items      []flashcards.Item      `json:"items"`
 	Difficulty *difficulty.Difficulty `json:"difficulty"`
+
+	// New words and reviews left for the day, counted before the returned
+	// items are reviewed.
+	// Omitted if the user has no daily limits.
+	Quota *word_scheduler.Quota `json:"quota,omitempty"`
 }
 
 type SetCourseRequest struct {
@@ -38,3 +54,32 @@ type SetCourseRequest struct {
 type SetCourseResponse struct {
 	Ok bool `json:"ok"`
 }
+
+// Request for adding or deleting a custom sentence.
+type CustomSentenceRequest struct {
+	ID          int    `json:"id"`
+	Text        string `json:"text"`
+	Translation string `json:"translation"`
+	CSRFToken   string `json:"csrfToken"`
+}
+
+type CustomSentencesResponse struct {
+	Sentences []custom.Sentence `json:"sentences"`
+}
+
+type WordActionRequest struct {
+	Word string `json:"word"`
+
+	// "suspend", "unsuspend", "drill", "reset", "bury", "unbury" or "known".
+	Action string `json:"action"`
+
+	// Number of days to bury the word, or the interval of a known word.
+	// Uses the default if zero.
+	Days int `json:"days,omitempty"`
+
+	CSRFToken string `json:"csrfToken"`
+}
+
+type OkResponse struct {
+	Ok bool `json:"ok"`
+}
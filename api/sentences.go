@@ -15,8 +15,9 @@ import (
 )
 
 // Gets limit from URL query.
-// Returns a default value if the parameter is missing, invalid or too big.
-func getSentencesLimit(q url.Values) int {
+// Returns a default value if the parameter is missing or invalid, and max if
+// it's too big.
+func getSentencesLimit(q url.Values, max int) int {
 	v := q.Get("limit")
 	limit, err := strconv.Atoi(v)
 	if err != nil {
@@ -25,8 +26,8 @@ func getSentencesLimit(q url.Values) int {
 	if limit <= 0 {
 		return 1
 	}
-	if limit > 1000 {
-		return 1000
+	if limit > max {
+		return max
 	}
 	return limit
 }
@@ -50,7 +51,7 @@ func handleSentences(w http.ResponseWriter, r *http.Request) {
 	}
 	defer db.Close()
 
-	limit := getSentencesLimit(q)
+	limit := getSentencesLimit(q, getConfig(r).maxSentences())
 	result, err := sentences.RandomSentences(db, limit)
 	if err != nil {
 		log.Println(err)
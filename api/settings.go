@@ -15,6 +15,7 @@ import (
 	"github.com/polycloze/polycloze/auth"
 	"github.com/polycloze/polycloze/basedir"
 	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
 	"github.com/polycloze/polycloze/sessions"
 )
 
@@ -32,7 +33,7 @@ func handleSettings(w http.ResponseWriter, r *http.Request) {
 		newPassword := r.FormValue("new-password")
 		csrfToken := r.FormValue("csrf-token")
 
-		if !sessions.CheckCSRFToken(s.ID, csrfToken) {
+		if !s.CheckCSRFToken(csrfToken) {
 			_ = s.ErrorMessage(
 				"Something went wrong. Please try again.",
 				"change-password",
@@ -40,10 +41,14 @@ func handleSettings(w http.ResponseWriter, r *http.Request) {
 			goto fail
 		}
 
-		id, err := auth.Authenticate(db, username, currentPassword)
-		if err != nil {
-			log.Println(err)
-			_ = s.ErrorMessage("Incorrect password.", "change-password")
+		if message := getConfig(r).checkPassword(newPassword); message != "" {
+			_ = s.ErrorMessage(message, "change-password")
+			goto fail
+		}
+
+		id, message := authenticateThrottled(r, db, username, currentPassword)
+		if message != "" {
+			_ = s.ErrorMessage(message, "change-password")
 			goto fail
 		}
 
@@ -68,11 +73,82 @@ fail:
 		return
 	}
 
+	// Get access tokens.
+	tokens, err := auth.ListAccessTokens(db, userID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	s.Data["accessTokens"] = tokens
+	s.Data["accessTokenMessages"], _ = s.Messages("access-tokens")
+
+	// Get signed-in devices.
+	devices, err := sessions.ListSessions(db, userID, s.ID)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	s.Data["devices"] = devices
+	s.Data["sessionMessages"], _ = s.Messages("sessions")
+
+	// Get review scheduler.
+	userDB, err := database.OpenUserDB(basedir.UserData(userID))
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	defer userDB.Close()
+
+	scheduler, err := getSchedulerName(userDB, course.L1.Code, course.L2.Code)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	lemmaMode, err := isLemmaMode(userDB, course.L1.Code, course.L2.Code)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	leechThreshold, err := getLeechThreshold(userDB, course.L1.Code, course.L2.Code)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	limits, err := getDailyLimits(userDB, course.L1.Code, course.L2.Code)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
 	s.Data["course"] = course
 	s.Data["csrfToken"] = sessions.CSRFToken(s.ID)
+	s.Data["scheduler"] = scheduler
+	s.Data["schedulers"] = rs.Names()
 	s.Data["changePasswordMessages"], _ = s.Messages("change-password")
+	s.Data["accountMessages"], _ = s.Messages("account")
+	s.Data["deleteAccountMessages"], _ = s.Messages("delete-account")
 	s.Data["csvUploadMessages"], _ = s.Messages("csv-upload")
 	s.Data["resetProgressMessages"], _ = s.Messages("reset-progress")
+	s.Data["schedulerMessages"], _ = s.Messages("scheduler")
+	s.Data["lemmaMode"] = lemmaMode
+	s.Data["lemmaMessages"], _ = s.Messages("lemma")
+	s.Data["leechThreshold"] = leechThreshold
+	s.Data["minLeechThreshold"] = minLeechThreshold
+	s.Data["maxLeechThreshold"] = maxLeechThreshold
+	s.Data["leechMessages"], _ = s.Messages("leeches")
+	s.Data["limits"] = limits
+	s.Data["maxDailyLimit"] = maxDailyLimit
+	s.Data["limitMessages"], _ = s.Messages("limits")
 	renderTemplate(w, "settings.html", s.Data)
 }
 
@@ -90,10 +166,10 @@ func handleResetProgress(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check if user is signed in.
+	// Progress can only be reset from browser sessions.
 	db := auth.GetDB(r)
 	s, err := sessions.ResumeSession(db, w, r)
-	if err != nil || !s.IsSignedIn() {
+	if err != nil || !s.IsSignedIn() || s.IsStateless() {
 		http.NotFound(w, r)
 		return
 	}
@@ -103,7 +179,7 @@ func handleResetProgress(w http.ResponseWriter, r *http.Request) {
 	confirm := r.FormValue("confirm")
 
 	// Check CSRF token.
-	if !sessions.CheckCSRFToken(s.ID, csrfToken) {
+	if !s.CheckCSRFToken(csrfToken) {
 		_ = s.ErrorMessage(
 			"Something went wrong. Please try again.",
 			"reset-progress",
@@ -117,6 +193,9 @@ func handleResetProgress(w http.ResponseWriter, r *http.Request) {
 		goto fail
 	}
 
+	// Pooled handles would keep using the deleted file.
+	invalidateReviewDB(r, userID, l1, l2)
+	invalidateFlashcards(r, userID, l1, l2)
 	if err := resetProgress(userID, l1, l2); err != nil {
 		log.Println(err)
 		_ = s.ErrorMessage(
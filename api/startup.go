@@ -16,6 +16,9 @@ import (
 // Version string of course files.
 var dataVersion string
 
+// Courses found at startup.
+var loadedCourses []Course
+
 type Language struct {
 	Code  string `json:"code"` // ISO 639-3
 	Name  string `json:"name"` // in english
@@ -53,6 +56,7 @@ func Startup() {
 		log.Fatal("Couldn't set version number.")
 	}
 	dataVersion = string(version)
+	loadedCourses = courses
 
 	// Generate courses.json.
 	coursesJSON := filepath.Join(basedir.StateDir, "courses.json")
@@ -61,13 +61,13 @@ func handleStatsActivity(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := s.Data["userID"].(int)
-	db, err = database.OpenReviewDB(basedir.Review(userID, l1, l2))
+	db, release, err := openReviewDB(r, userID, l1, l2)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
+	defer release()
 
 	result, err := history.Summarize(
 		db,
@@ -104,13 +104,13 @@ func handleStatsVocab(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := s.Data["userID"].(int)
-	db, err = database.OpenReviewDB(basedir.Review(userID, l1, l2))
+	db, release, err := openReviewDB(r, userID, l1, l2)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
+	defer release()
 
 	result, err := history.VocabSize(
 		db,
@@ -147,13 +147,13 @@ func handleStatsEstimatedLevel(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := s.Data["userID"].(int)
-	db, err = database.OpenReviewDB(basedir.Review(userID, l1, l2))
+	db, release, err := openReviewDB(r, userID, l1, l2)
 	if err != nil {
 		log.Println(err)
 		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
+	defer release()
 
 	result, err := history.EstimatedLevel(
 		db,
@@ -0,0 +1,97 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Personal access token management.
+package api
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Creates access token and shows it to the user.
+// The token is rendered directly instead of being passed around in session
+// messages, so it never gets stored in plaintext.
+func handleCreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Access tokens can only be managed from browser sessions.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() || s.IsStateless() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+	name := strings.TrimSpace(r.FormValue("name"))
+
+	if !s.CheckCSRFToken(r.FormValue("csrf-token")) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"access-tokens",
+		)
+		http.Redirect(w, r, "/settings", http.StatusSeeOther)
+		return
+	}
+
+	token, err := auth.CreateAccessToken(db, userID, name)
+	if err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"access-tokens",
+		)
+		http.Redirect(w, r, "/settings", http.StatusSeeOther)
+		return
+	}
+
+	s.Data["csrfToken"] = sessions.CSRFToken(s.ID)
+	s.Data["tokenName"] = name
+	s.Data["token"] = token
+	renderTemplate(w, "token.html", s.Data)
+}
+
+func handleRevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "POST" {
+		http.Error(w, "expected POST request", http.StatusBadRequest)
+		return
+	}
+
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() || s.IsStateless() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+
+	if !s.CheckCSRFToken(r.FormValue("csrf-token")) {
+		_ = s.ErrorMessage(
+			"Something went wrong. Please try again.",
+			"access-tokens",
+		)
+		goto fail
+	}
+
+	if id, err := strconv.Atoi(r.FormValue("id")); err != nil {
+		_ = s.ErrorMessage("Invalid access token.", "access-tokens")
+		goto fail
+	} else if err := auth.RevokeAccessToken(db, userID, id); err != nil {
+		log.Println(err)
+		_ = s.ErrorMessage("Invalid access token.", "access-tokens")
+		goto fail
+	}
+
+	_ = s.SuccessMessage("Access token revoked.", "access-tokens")
+
+fail:
+	http.Redirect(w, r, "/settings", http.StatusSeeOther)
+}
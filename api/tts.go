@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Sentence audio from a local TTS command.
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/flashcards"
+	"github.com/polycloze/polycloze/sentences"
+	"github.com/polycloze/polycloze/sessions"
+)
+
+// Points flashcards without recorded audio to the TTS endpoint.
+// Does nothing if TTS isn't configured.
+func addTTSAudio(r *http.Request, l1, l2 string, items []flashcards.Item) {
+	if getConfig(r).TTS == nil {
+		return
+	}
+	for i := range items {
+		if items[i].Audio != "" {
+			continue
+		}
+		query := url.Values{"sentence": {strconv.Itoa(items[i].Sentence.ID)}}
+		if items[i].Sentence.Custom {
+			query.Set("custom", "true")
+		}
+		u := url.URL{
+			Path:     fmt.Sprintf("/api/tts/%v/%v", l1, l2),
+			RawQuery: query.Encode(),
+		}
+		items[i].Audio = u.String()
+	}
+}
+
+// Serves audio generated by the TTS command.
+// The text is looked up from the sentence ID, so that only course and custom
+// sentences can be synthesized.
+// Query params: sentence, custom
+func handleTTS(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		http.Error(w, "expected GET request", http.StatusBadRequest)
+		return
+	}
+
+	command := getConfig(r).TTS
+	if command == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Check if user is signed in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+
+	query := r.URL.Query()
+	id, err := strconv.Atoi(query.Get("sentence"))
+	if err != nil {
+		http.Error(w, "Invalid sentence.", http.StatusBadRequest)
+		return
+	}
+	custom := query.Get("custom") == "true"
+
+	// Course and custom sentence DBs are attached to the review DB.
+	reviewDB, release, err := openReviewDB(r, userID, l1, l2)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	text, err := sentences.GetText(reviewDB, id, custom)
+	release()
+	if errors.Is(err, sql.ErrNoRows) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	course, err := getCourseInfo(basedir.Course(l1, l2))
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	name, err := command.Synthesize(r.Context(), course.L2.BCP47, text)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	// Same sentence always produces the same audio.
+	w.Header().Set("Cache-Control", "private, max-age=86400")
+	http.ServeFile(w, r, name)
+}
@@ -12,16 +12,13 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/polycloze/polycloze/auth"
-	"github.com/polycloze/polycloze/basedir"
-	"github.com/polycloze/polycloze/database"
 	"github.com/polycloze/polycloze/replay"
 	"github.com/polycloze/polycloze/sessions"
 )
 
 // Checks if uploaded file size is too big.
-func isTooBig(size int64) bool {
-	// Limit to 8MB.
-	return size > 8*1024*1024
+func isTooBig(size, max int64) bool {
+	return size > max
 }
 
 func handleUpload(w http.ResponseWriter, r *http.Request) {
@@ -47,11 +44,12 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 	}
 	var message string
 	var success bool
+	var release func()
 	userID := s.Data["userID"].(int)
 
 	// Check CSRF token.
 	csrfToken := r.FormValue("csrf-token")
-	if !sessions.CheckCSRFToken(s.ID, csrfToken) {
+	if !s.CheckCSRFToken(csrfToken) {
 		http.Error(w, "Forbidden.", http.StatusForbidden)
 		return
 	}
@@ -71,28 +69,42 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 		goto fail
 	}
 
-	if isTooBig(header.Size) {
-		message = "File is too big (>8MB)."
+	if isTooBig(header.Size, getConfig(r).maxUploadSize()) {
+		message = fmt.Sprintf("File is too big (>%vMB).", getConfig(r).maxUploadSize()/(1024*1024))
 		_ = s.ErrorMessage(message, "csv-upload")
 		goto fail
 	}
 
 	// Open user's review DB.
 	// TODO import into a new db instead?
-	db, err = database.OpenReviewDB(basedir.Review(userID, l1, l2))
+	db, release, err = openReviewDB(r, userID, l1, l2)
 	if err != nil {
 		log.Println(fmt.Errorf("could not open review database (%v-%v): %w", l1, l2, err))
 		message = "Something went wrong. Please try again."
 		_ = s.ErrorMessage(message, "csv-upload")
 		goto fail
 	}
-	defer db.Close()
-
-	// TODO connect to course db to filter out reviews that are not in the course
-	// database?
-	if err := replay.Replay(db, file); err != nil {
+	defer release()
+
+	// TODO use attached course db to filter out reviews that are not in the
+	// course database?
+	if r.FormValue("merge") == "true" {
+		scheduler, err := getUserScheduler(userID, l1, l2)
+		if err != nil {
+			log.Println(err)
+			message = "Something went wrong. Please try again."
+			_ = s.ErrorMessage(message, "csv-upload")
+			goto fail
+		}
+		if err := replay.Merge(db, scheduler, file); err != nil {
+			log.Println(err)
+			message = "Something went wrong. Please try again."
+			_ = s.ErrorMessage(message, "csv-upload")
+			goto fail
+		}
+	} else if err := replay.Replay(db, file); err != nil {
 		if errors.Is(err, replay.ErrHasExistingReviews) {
-			message = "Can't import data, because existing reviews were found. Try resetting your progress first."
+			message = "Can't import data, because existing reviews were found. Try merging with your existing progress or resetting your progress first."
 			_ = s.ErrorMessage(message, "csv-upload")
 			goto fail
 		}
@@ -103,6 +115,7 @@ func handleUpload(w http.ResponseWriter, r *http.Request) {
 		goto fail
 	}
 
+	invalidateFlashcards(r, userID, l1, l2)
 	success = true
 	message = "File uploaded."
 	_ = s.SuccessMessage(message, "csv-upload")
@@ -0,0 +1,55 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Settings stored in the user DB.
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Returns name of course setting in the `user_data` table.
+func courseSettingName(l1, l2, name string) string {
+	return fmt.Sprintf("%v/%v-%v", name, l1, l2)
+}
+
+// Gets setting from the user DB.
+// Returns an empty string without errors if the setting hasn't been set.
+func getUserSetting(db *sql.DB, name string) (string, error) {
+	query := `SELECT value FROM user_data WHERE name = ?`
+
+	var value sql.NullString
+	err := db.QueryRow(query, name).Scan(&value)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get setting (%v): %w", name, err)
+	}
+	return value.String, nil
+}
+
+// Sets setting in the user DB.
+func setUserSetting(db *sql.DB, name, value string) error {
+	query := `
+		INSERT OR REPLACE INTO user_data (name, value)
+		VALUES (?, ?)
+	`
+	if _, err := db.Exec(query, name, value); err != nil {
+		return fmt.Errorf("failed to set setting (%v): %w", name, err)
+	}
+	return nil
+}
+
+// Gets course setting from the user DB.
+// Returns an empty string without errors if the setting hasn't been set.
+func getCourseSetting(db *sql.DB, l1, l2, name string) (string, error) {
+	return getUserSetting(db, courseSettingName(l1, l2, name))
+}
+
+// Sets course setting in the user DB.
+func setCourseSetting(db *sql.DB, l1, l2, name, value string) error {
+	return setUserSetting(db, courseSettingName(l1, l2, name), value)
+}
@@ -9,14 +9,15 @@ import (
 	"log"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/polycloze/polycloze/auth"
-	"github.com/polycloze/polycloze/basedir"
-	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/sentences"
 	"github.com/polycloze/polycloze/sessions"
 )
 
@@ -26,6 +27,15 @@ type Word struct {
 	Reviewed time.Time `json:"reviewed"`
 	Due      time.Time `json:"due"`
 	Strength int       `json:"strength"`
+
+	// Inflected forms if the word is a lemma with more than one form.
+	Forms []string `json:"forms,omitempty"`
+
+	// "suspended", "drilling", "buried", "known" or empty.
+	State string `json:"state,omitempty"`
+
+	// Only set if the word is buried.
+	BuriedUntil *time.Time `json:"buriedUntil,omitempty"`
 }
 
 func handleVocabulary(w http.ResponseWriter, r *http.Request) {
@@ -44,16 +54,31 @@ func handleVocabulary(w http.ResponseWriter, r *http.Request) {
 	}
 
 	userID := s.Data["userID"].(int)
-	db, err = database.OpenReviewDB(basedir.Review(userID, l1, l2))
+	db, release, err := openReviewDB(r, userID, l1, l2)
 	if err != nil {
 		log.Println(fmt.Errorf("could not open review database (%v-%v): %w", l1, l2, err))
 		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
 		return
 	}
-	defer db.Close()
+	defer release()
 
 	q := r.URL.Query()
-	results, err := searchVocabulary(db, getLimit(q), getAfter(q), getSortBy(q))
+
+	// Only list leeches if requested.
+	leeches := ""
+	if q.Get("filter") == "leech" {
+		threshold, err := getUserLeechThreshold(userID, l1, l2)
+		if err == nil {
+			leeches, err = rs.LeechesJSON(db, threshold)
+		}
+		if err != nil {
+			log.Println(err)
+			http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	results, err := searchVocabulary(db, getLimit(q), getAfter(q), getSortBy(q), leeches)
 	if err != nil {
 		log.Println(fmt.Errorf("search error: %w", err))
 		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
@@ -105,11 +130,10 @@ func getSortBy(q url.Values) string {
 	return "word"
 }
 
-// Returns map from interval (as number of hours) to strength.
-// Use this to compute interval strength.
-// The result is not the same as `interval.ROWID`, because there can be gaps in
-// rowids.
-func queryIntervalStrengths(db *sql.DB) (map[int]int, error) {
+// Returns intervals (as number of hours) in the `interval` table, in
+// ascending order.
+// Use this to compute interval strength (see intervalStrength).
+func queryIntervals(db *sql.DB) ([]int, error) {
 	query := `SELECT interval FROM interval ORDER BY interval ASC`
 	rows, err := db.Query(query)
 	if err != nil {
@@ -117,23 +141,36 @@ func queryIntervalStrengths(db *sql.DB) (map[int]int, error) {
 	}
 	defer rows.Close()
 
-	var strength int
-	intervals := make(map[int]int)
+	var intervals []int
 	for rows.Next() {
 		var interval int
 		if err := rows.Scan(&interval); err != nil {
 			return nil, fmt.Errorf("failed to query intervals: %w", err)
 		}
-		intervals[interval] = strength
-		strength++
+		intervals = append(intervals, interval)
 	}
 	return intervals, nil
 }
 
+// Returns strength of the interval, i.e. the index of the largest interval in
+// the `interval` table that isn't longer than it.
+// Intervals set by other schedulers don't have to be in the table.
+// The result is not the same as `interval.ROWID`, because there can be gaps in
+// rowids.
+func intervalStrength(intervals []int, interval int) int {
+	strength := sort.SearchInts(intervals, interval+1) - 1
+	if strength < 0 {
+		return 0
+	}
+	return strength
+}
+
 // Lists words returned by query.
 //   - limit should be between 10 and 100.
 //     Silently changes limit if not.
-func searchVocabulary(db *sql.DB, limit int, after string, sortBy string) ([]Word, error) {
+//   - leeches is a JSON array of words to filter by.
+//     Doesn't filter words if empty.
+func searchVocabulary(db *sql.DB, limit int, after string, sortBy string, leeches string) ([]Word, error) {
 	// Cap limit.
 	if limit < 10 {
 		limit = 10
@@ -146,21 +183,32 @@ func searchVocabulary(db *sql.DB, limit int, after string, sortBy string) ([]Wor
 		panic(fmt.Errorf("invalid sortBy value: %v", sortBy))
 	}
 
-	intervals, err := queryIntervalStrengths(db)
+	intervals, err := queryIntervals(db)
 	if err != nil {
 		return nil, fmt.Errorf("vocabulary search failed: %w", err)
 	}
 
+	filter := ""
+	args := []any{after}
+	if leeches != "" {
+		filter = `AND item IN (SELECT value FROM json_each(?))`
+		args = append(args, leeches)
+	}
+
+	// Doesn't join the `interval` table, because other schedulers can set
+	// intervals that aren't in it.
 	query := fmt.Sprintf(`
-		SELECT item AS word, learned, reviewed, due, interval AS strength
-		FROM review JOIN interval USING (interval)
-		WHERE item > ?
+		SELECT review.item AS word, learned, reviewed, due, interval AS strength,
+			coalesce(state, ''), buried_until, known_until
+		FROM review
+		LEFT JOIN word_state ON (word_state.item = review.item)
+		WHERE review.item > ? %s
 		ORDER BY %s
 		LIMIT ?
-	`, sortBy)
+	`, filter, sortBy)
 
 	words := make([]Word, 0)
-	rows, err := db.Query(query, after, limit)
+	rows, err := db.Query(query, append(args, limit)...)
 	if err != nil {
 		return nil, fmt.Errorf("vocabulary search failed: %w", err)
 	}
@@ -170,14 +218,47 @@ func searchVocabulary(db *sql.DB, limit int, after string, sortBy string) ([]Wor
 		var vocab Word
 		var learned, reviewed, due int64
 		var interval int
-		if err := rows.Scan(&vocab.Word, &learned, &reviewed, &due, &interval); err != nil {
+		var buriedUntil, knownUntil sql.NullInt64
+		if err := rows.Scan(&vocab.Word, &learned, &reviewed, &due, &interval, &vocab.State, &buriedUntil, &knownUntil); err != nil {
 			return nil, fmt.Errorf("vocabulary search failed: %w", err)
 		}
+		if vocab.State == rs.Buried && buriedUntil.Valid {
+			until := time.Unix(buriedUntil.Int64, 0)
+			vocab.BuriedUntil = &until
+		}
 		vocab.Learned = time.Unix(learned, 0)
 		vocab.Reviewed = time.Unix(reviewed, 0)
 		vocab.Due = time.Unix(due, 0)
-		vocab.Strength = intervals[interval]
+
+		// Known words are hidden until the interval is over.
+		if vocab.State == rs.Known && knownUntil.Valid && knownUntil.Int64 > due {
+			vocab.Due = time.Unix(knownUntil.Int64, 0)
+		}
+		vocab.Strength = intervalStrength(intervals, interval)
 		words = append(words, vocab)
 	}
+	rows.Close()
+
+	if err := addLemmaForms(db, words); err != nil {
+		return nil, fmt.Errorf("vocabulary search failed: %w", err)
+	}
 	return words, nil
 }
+
+// Adds inflected forms to words that are lemmas of more than one form.
+// Does nothing if the course doesn't have lemmas.
+func addLemmaForms(db *sql.DB, words []Word) error {
+	if !sentences.HasLemmas(db) {
+		return nil
+	}
+	for i := range words {
+		forms, err := sentences.LemmaForms(db, words[i].Word)
+		if err != nil {
+			return err
+		}
+		if len(forms) > 1 {
+			words[i].Forms = forms
+		}
+	}
+	return nil
+}
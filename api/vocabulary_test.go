@@ -0,0 +1,52 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"testing"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+func TestIntervalStrength(t *testing.T) {
+	t.Parallel()
+
+	intervals := []int{0, 24, 48, 96}
+	cases := map[int]int{
+		0:    0,
+		24:   1,
+		30:   1, // Not in the table
+		96:   3,
+		4320: 3,
+	}
+	for interval, expected := range cases {
+		if strength := intervalStrength(intervals, interval); strength != expected {
+			t.Fatalf("expected strength of %v to be %v, got %v", interval, expected, strength)
+		}
+	}
+}
+
+func TestSearchVocabularyIntervalNotInTable(t *testing.T) {
+	t.Parallel()
+
+	db, err := database.OpenReviewDB(":memory:")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer db.Close()
+
+	// Other schedulers can set intervals that aren't in the `interval` table.
+	query := `INSERT INTO review (item, interval, learned, reviewed) VALUES (?, ?, 0, 0)`
+	if _, err := db.Exec(query, "foo", 30); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	words, err := searchVocabulary(db, 10, "", "word", "")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(words) != 1 || words[0].Word != "foo" {
+		t.Fatal("expected word with interval not in the table to be listed:", words)
+	}
+}
@@ -104,7 +104,7 @@ func handleWelcome(w http.ResponseWriter, r *http.Request) {
 			goto show
 		}
 
-		if !sessions.CheckCSRFToken(s.ID, csrfToken) {
+		if !s.CheckCSRFToken(csrfToken) {
 			_ = s.ErrorMessage("Something went wrong. Please try again.", "welcome")
 			goto show
 		}
@@ -0,0 +1,133 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Vocabulary actions (suspend, bury, mark as known, etc.).
+package api
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/polycloze/polycloze/auth"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/sessions"
+	"github.com/polycloze/polycloze/text"
+)
+
+// Max number of days in WordActionRequest.
+const maxActionDays = 3650
+
+func isValidWordAction(action string) bool {
+	switch action {
+	case "suspend", "unsuspend", "drill", "reset", "bury", "unbury", "known":
+		return true
+	default:
+		return false
+	}
+}
+
+// Applies vocabulary action to a word.
+// days is ignored by actions that don't need it.
+func applyWordAction(db *sql.DB, word, action string, days int, now time.Time) error {
+	switch action {
+	case "suspend":
+		return rs.SetWordState(db, word, rs.Suspended)
+	case "unsuspend":
+		return rs.ClearWordState(db, word, rs.Suspended)
+	case "unbury":
+		return rs.ClearWordState(db, word, rs.Buried)
+	case "drill":
+		return rs.SetWordState(db, word, rs.Drilling)
+	case "reset":
+		return rs.ResetWord(db, word)
+	case "bury":
+		if days <= 0 {
+			days = 1
+		}
+		return rs.BuryWord(db, word, now.AddDate(0, 0, days))
+	case "known":
+		interval := time.Duration(days) * 24 * time.Hour
+		return rs.MarkKnown(db, word, interval, now)
+	default:
+		return fmt.Errorf("unknown vocabulary action: %v", action)
+	}
+}
+
+// Sets state of a word in the user's vocabulary.
+// Also works on words the user hasn't seen yet.
+func handleWordAction(w http.ResponseWriter, r *http.Request) {
+	// Check request method and content type.
+	if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" {
+		http.Error(w, "expected JSON body in POST request", http.StatusBadRequest)
+		return
+	}
+
+	// Check if course exists.
+	l1 := chi.URLParam(r, "l1")
+	l2 := chi.URLParam(r, "l2")
+	if !courseExists(l1, l2) {
+		http.NotFound(w, r)
+		return
+	}
+
+	// Sign in.
+	db := auth.GetDB(r)
+	s, err := sessions.ResumeSession(db, w, r)
+	if err != nil || !s.IsSignedIn() {
+		http.NotFound(w, r)
+		return
+	}
+	userID := s.Data["userID"].(int)
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		log.Println(err)
+		http.Error(w, "Could not read request.", http.StatusInternalServerError)
+		return
+	}
+
+	var data WordActionRequest
+	if err := parseJSON(w, body, &data); err != nil {
+		return
+	}
+
+	// Look for csrf token in request headers or in the request body.
+	token := r.Header.Get("X-CSRF-Token")
+	if token == "" {
+		token = data.CSRFToken
+	}
+	if !s.CheckAPICSRFToken(token) {
+		http.Error(w, "Forbidden.", http.StatusForbidden)
+		return
+	}
+
+	word := text.Casefold(data.Word)
+	if word == "" || !isValidWordAction(data.Action) || data.Days < 0 || data.Days > maxActionDays {
+		http.Error(w, "Invalid vocabulary action.", http.StatusBadRequest)
+		return
+	}
+
+	db, release, err := openReviewDB(r, userID, l1, l2)
+	if err != nil {
+		log.Println(fmt.Errorf("could not open review database (%v-%v): %w", l1, l2, err))
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+	defer release()
+
+	if err := applyWordAction(db, word, data.Action, data.Days, time.Now()); err != nil {
+		log.Println(err)
+		http.Error(w, "Something went wrong.", http.StatusInternalServerError)
+		return
+	}
+
+	// Prefetched flashcards may contain the word.
+	invalidateFlashcards(r, userID, l1, l2)
+	sendJSON(w, OkResponse{Ok: true})
+}
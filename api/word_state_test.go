@@ -0,0 +1,50 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package api
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+func TestWordActionsOnlyUndoTheirOwnState(t *testing.T) {
+	// Unburying shouldn't unsuspend words, and vice versa.
+	t.Parallel()
+
+	db, err := database.OpenReviewDB(filepath.Join(t.TempDir(), "review.db"))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	actions := [][3]string{
+		{"foo", "suspend", "suspended"},
+		{"foo", "unbury", "suspended"},
+		{"bar", "bury", "buried"},
+		{"bar", "unsuspend", "buried"},
+		{"baz", "known", "known"},
+		{"baz", "unsuspend", "known"},
+		{"foo", "unsuspend", ""},
+		{"bar", "unbury", ""},
+	}
+	for _, action := range actions {
+		word, name, expected := action[0], action[1], action[2]
+		if err := applyWordAction(db, word, name, 0, now); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+
+		var state string
+		query := `SELECT coalesce(max(state), '') FROM word_state WHERE item = ?`
+		if err := db.QueryRow(query, word).Scan(&state); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		if state != expected {
+			t.Fatalf("expected %v to be %q after %v, got %q", word, expected, name, state)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Account management.
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Deletes user from the auth DB.
+// Sessions and access tokens of the user get deleted too.
+// Doesn't delete the user's files.
+func DeleteUser(db *sql.DB, userID int) error {
+	result, err := db.Exec(`DELETE FROM user WHERE id = ?`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errors.New("failed to delete user: user not found")
+	}
+	return nil
+}
+
+// Changes username.
+// Also updates the username in the user's active sessions.
+func ChangeUsername(db *sql.DB, userID int, username string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to change username: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `UPDATE user SET username = ? WHERE id = ?`
+	if _, err := tx.Exec(query, username, userID); err != nil {
+		return errors.New("unable to change username")
+	}
+
+	query = `UPDATE user_session SET username = ? WHERE user_id = ?`
+	if _, err := tx.Exec(query, username, userID); err != nil {
+		return fmt.Errorf("failed to change username: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to change username: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package auth
+
+import (
+	"testing"
+)
+
+func TestDeleteUser(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("initial registration should succeed:", err)
+	}
+	id, err := Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := CreateAccessToken(db, id, "test"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if err := DeleteUser(db, id); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := Authenticate(db, "foo", "bar"); err == nil {
+		t.Fatal("deleted user shouldn't be able to sign in")
+	}
+
+	var count int
+	query := `SELECT count(*) FROM access_token WHERE user_id = ?`
+	if err := db.QueryRow(query, id).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 0 {
+		t.Fatal("expected access tokens of deleted user to be deleted")
+	}
+
+	if err := DeleteUser(db, id); err == nil {
+		t.Fatal("expected deleting a missing user to fail")
+	}
+}
+
+func TestChangeUsername(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	for _, username := range []string{"foo", "bar"} {
+		if err := Register(db, username, "password"); err != nil {
+			t.Fatal("initial registration should succeed:", err)
+		}
+	}
+	id, err := Authenticate(db, "foo", "password")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	query := `INSERT INTO user_session (session_id, user_id, username) VALUES (?, ?, ?)`
+	if _, err := db.Exec(query, "session", id, "foo"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if err := ChangeUsername(db, id, "bar"); err == nil {
+		t.Fatal("expected taken username to be rejected")
+	}
+	if err := ChangeUsername(db, id, "baz"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := Authenticate(db, "baz", "password"); err != nil {
+		t.Fatal("expected user to sign in with new username:", err)
+	}
+
+	var username string
+	query = `SELECT username FROM user_session WHERE session_id = 'session'`
+	if err := db.QueryRow(query).Scan(&username); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if username != "baz" {
+		t.Fatal("expected session username to be updated:", username)
+	}
+}
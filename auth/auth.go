@@ -7,22 +7,25 @@ package auth
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
-func saltHashPassword(password string) string {
-	result, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+var errAuthenticationFailed = errors.New("unable to authenticate user")
+
+// Registers user.
+// The password should at least be allowed by the zero PasswordPolicy.
+func Register(db *sql.DB, username, password string) error {
+	if err := (PasswordPolicy{}).Check(password); err != nil {
+		return err
+	}
+	hash, err := saltHashPassword(password)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	return string(result)
-}
 
-func Register(db *sql.DB, username, password string) error {
-	// NOTE allows empty string as password
 	query := `INSERT INTO user (username, password) VALUES (?, ?)`
-	hash := saltHashPassword(password)
 	if _, err := db.Exec(query, username, hash); err != nil {
 		return errors.New("unable to register user")
 	}
@@ -31,24 +34,47 @@ func Register(db *sql.DB, username, password string) error {
 
 // Validates credentials.
 // Returns user ID on success.
+// Upgrades the password hash if it's weaker than new hashes.
 func Authenticate(db *sql.DB, username, password string) (int, error) {
 	var id int
 	var hash string
 	query := `SELECT id, password FROM user WHERE username = ?`
 	err := db.QueryRow(query, username).Scan(&id, &hash)
-
-	if err != nil && hash != "" {
-		panic("something unexpected occurred")
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errAuthenticationFailed
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to authenticate user: %w", err)
 	}
+
 	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
-		return id, errors.New("unable to authenticate user")
+		return 0, errAuthenticationFailed
+	}
+
+	if needsRehash(hash) {
+		// Not fatal: the user can still sign in with the old hash.
+		_ = setPassword(db, id, password)
 	}
 	return id, nil
 }
 
+// Changes the user's password.
+// The password should at least be allowed by the zero PasswordPolicy.
 func ChangePassword(db *sql.DB, userID int, password string) error {
+	if err := (PasswordPolicy{}).Check(password); err != nil {
+		return err
+	}
+	return setPassword(db, userID, password)
+}
+
+// Replaces the user's password hash without checking the password policy.
+func setPassword(db *sql.DB, userID int, password string) error {
+	hash, err := saltHashPassword(password)
+	if err != nil {
+		return err
+	}
+
 	query := `UPDATE user SET password = ? WHERE id = ?`
-	hash := saltHashPassword(password)
 	if _, err := db.Exec(query, hash, userID); err != nil {
 		return errors.New("unable to update password")
 	}
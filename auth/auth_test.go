@@ -5,6 +5,7 @@ package auth
 
 import (
 	"database/sql"
+	"errors"
 	"strings"
 	"testing"
 
@@ -110,11 +111,11 @@ func TestRegisterEmptyPassword(t *testing.T) {
 	db := openDB()
 	defer db.Close()
 
-	if err := Register(db, "username", ""); err != nil {
-		t.Fatal("empty string should be allowed as password:", err)
+	if err := Register(db, "username", ""); !errors.Is(err, ErrPasswordTooShort) {
+		t.Fatal("empty string shouldn't be allowed as password:", err)
 	}
-	if _, err := Authenticate(db, "username", ""); err != nil {
-		t.Fatal("empty string should be allowed as password:", err)
+	if _, err := Authenticate(db, "username", ""); err == nil {
+		t.Fatal("user with empty password shouldn't be registered")
 	}
 }
 
@@ -7,6 +7,9 @@ import (
 	"context"
 	"database/sql"
 	"net/http"
+	"strings"
+
+	"github.com/polycloze/polycloze/sessions"
 )
 
 type contextValueKey int
@@ -32,3 +35,40 @@ func Middleware(db *sql.DB) func(http.Handler) http.Handler {
 func GetDB(r *http.Request) *sql.DB {
 	return r.Context().Value(keyUserDB).(*sql.DB)
 }
+
+// Gets bearer token from the request's Authorization header.
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	scheme, token, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+// Authenticates requests with an `Authorization: Bearer <token>` header.
+// Authenticated requests resume a stateless session for the token's owner, so
+// handlers see the same user identity as in cookie-based sessions.
+// Requests without bearer tokens are passed through unchanged.
+func BearerMiddleware(db *sql.DB) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, ok := bearerToken(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			userID, username, err := AuthenticateAccessToken(db, token)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				http.Error(w, "Unauthorized.", http.StatusUnauthorized)
+				return
+			}
+
+			s := sessions.NewStatelessSession(db, userID, username)
+			next.ServeHTTP(w, sessions.WithSession(r, s))
+		})
+	}
+}
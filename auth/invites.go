@@ -0,0 +1,83 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Invite codes for invite-only registration.
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errInvalidInviteCode = errors.New("invalid invite code")
+
+// Creates an invite code that can be used the given number of times.
+// The code doesn't expire if expires is the zero time.
+// Returns the code, which can't be retrieved again.
+func CreateInviteCode(db *sql.DB, uses int, expires time.Time) (string, error) {
+	if uses <= 0 {
+		return "", errors.New("failed to create invite code: uses should be positive")
+	}
+
+	code, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to create invite code: %w", err)
+	}
+
+	var expiry sql.NullInt64
+	if !expires.IsZero() {
+		expiry = sql.NullInt64{Int64: expires.Unix(), Valid: true}
+	}
+
+	query := `INSERT INTO invite_code (code_hash, expires, uses_left) VALUES (?, ?, ?)`
+	if _, err := db.Exec(query, hashToken(code), expiry, uses); err != nil {
+		return "", fmt.Errorf("failed to create invite code: %w", err)
+	}
+	return code, nil
+}
+
+// Registers user if the invite code is valid, and uses up the code.
+func RegisterWithInviteCode(db *sql.DB, username, password, code string) error {
+	if err := (PasswordPolicy{}).Check(password); err != nil {
+		return err
+	}
+	hash, err := saltHashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to register user: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query := `
+		UPDATE invite_code SET uses_left = uses_left - 1
+		WHERE code_hash = ? AND uses_left > 0
+			AND (expires IS NULL OR expires > unixepoch('now'))
+	`
+	result, err := tx.Exec(query, hashToken(code))
+	if err != nil {
+		return fmt.Errorf("failed to register user: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return errInvalidInviteCode
+	}
+
+	query = `INSERT INTO user (username, password) VALUES (?, ?)`
+	if _, err := tx.Exec(query, username, hash); err != nil {
+		return errors.New("unable to register user")
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to register user: %w", err)
+	}
+	return nil
+}
+
+// Checks if the error is caused by an invalid invite code.
+func IsInvalidInviteCode(err error) bool {
+	return errors.Is(err, errInvalidInviteCode)
+}
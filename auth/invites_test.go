@@ -0,0 +1,76 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegisterWithInviteCode(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	code, err := CreateInviteCode(db, 1, time.Time{})
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if err := RegisterWithInviteCode(db, "foo", "bar", code); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := Authenticate(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	// The code can only be used once.
+	err = RegisterWithInviteCode(db, "baz", "bar", code)
+	if !IsInvalidInviteCode(err) {
+		t.Fatal("expected used up invite code to be invalid:", err)
+	}
+}
+
+func TestRegisterWithInvalidInviteCode(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	expired, err := CreateInviteCode(db, 1, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	for _, code := range []string{expired, "", "not-a-code"} {
+		err := RegisterWithInviteCode(db, "foo", "bar", code)
+		if !IsInvalidInviteCode(err) {
+			t.Fatalf("expected invite code %q to be invalid: %v", code, err)
+		}
+	}
+	if _, err := Authenticate(db, "foo", "bar"); err == nil {
+		t.Fatal("user shouldn't be registered without a valid invite code")
+	}
+}
+
+func TestRegisterWithInviteCodeTakenUsername(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	code, err := CreateInviteCode(db, 1, time.Time{})
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := RegisterWithInviteCode(db, "foo", "baz", code); err == nil {
+		t.Fatal("expected registration with taken username to fail")
+	}
+
+	// The code shouldn't be used up by the failed registration.
+	if err := RegisterWithInviteCode(db, "qux", "baz", code); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+}
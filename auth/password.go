@@ -0,0 +1,86 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Password policy and hashing.
+package auth
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// bcrypt cost of new password hashes.
+// Hashes with a lower cost get upgraded when the user signs in.
+const hashCost = 12
+
+// bcrypt ignores bytes after the 72nd.
+const maxPasswordLength = 72
+
+var (
+	ErrPasswordTooShort = errors.New("password is too short")
+	ErrPasswordTooLong  = errors.New("password is too long")
+	ErrBreachedPassword = errors.New("password appears in a list of breached passwords")
+)
+
+type PasswordPolicy struct {
+	// Min number of characters.
+	// Empty passwords are never allowed.
+	MinLength int
+
+	// Known breached passwords, which aren't allowed.
+	Breached map[string]bool
+}
+
+// Reads breached password list (one password per line).
+func LoadBreachedPasswords(path string) (map[string]bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load breached passwords: %w", err)
+	}
+	defer file.Close()
+
+	breached := make(map[string]bool)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if password := strings.TrimSpace(scanner.Text()); password != "" {
+			breached[password] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load breached passwords: %w", err)
+	}
+	return breached, nil
+}
+
+// Checks if the password is allowed by the policy.
+func (p PasswordPolicy) Check(password string) error {
+	if password == "" || len([]rune(password)) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > maxPasswordLength {
+		return ErrPasswordTooLong
+	}
+	if p.Breached[password] {
+		return ErrBreachedPassword
+	}
+	return nil
+}
+
+func saltHashPassword(password string) (string, error) {
+	result, err := bcrypt.GenerateFromPassword([]byte(password), hashCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(result), nil
+}
+
+// Checks if the hash should be replaced with a stronger one.
+func needsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost < hashCost
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	policy := PasswordPolicy{
+		MinLength: 8,
+		Breached:  map[string]bool{"password123": true},
+	}
+	cases := map[string]error{
+		"":                      ErrPasswordTooShort,
+		"short":                 ErrPasswordTooShort,
+		"ñáéíóúü":               ErrPasswordTooShort,
+		"password123":           ErrBreachedPassword,
+		strings.Repeat("a", 73): ErrPasswordTooLong,
+		"correct horse battery": nil,
+	}
+	for password, expected := range cases {
+		if err := policy.Check(password); !errors.Is(err, expected) {
+			t.Fatalf("expected %q to fail with %v, got %v", password, expected, err)
+		}
+	}
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte("123456\n\n  qwerty \npassword\n"), 0o600); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	breached, err := LoadBreachedPasswords(path)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(breached) != 3 || !breached["qwerty"] || breached[""] {
+		t.Fatal("unexpected breached passwords:", breached)
+	}
+}
+
+func TestChangePasswordEmpty(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	id, err := Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := ChangePassword(db, id, ""); !errors.Is(err, ErrPasswordTooShort) {
+		t.Fatal("expected empty password to be rejected:", err)
+	}
+	if _, err := Authenticate(db, "foo", "bar"); err != nil {
+		t.Fatal("password shouldn't have changed:", err)
+	}
+}
+
+func TestAuthenticateUpgradesHash(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	// Insert user with a weak hash.
+	weak, err := bcrypt.GenerateFromPassword([]byte("bar"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	query := `INSERT INTO user (username, password) VALUES (?, ?)`
+	if _, err := db.Exec(query, "foo", string(weak)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if _, err := Authenticate(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	var hash string
+	query = `SELECT password FROM user WHERE username = 'foo'`
+	if err := db.QueryRow(query).Scan(&hash); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if needsRehash(hash) {
+		t.Fatal("expected hash to be upgraded after sign-in")
+	}
+
+	// The upgraded hash should still work.
+	if _, err := Authenticate(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+}
+
+func TestAuthenticateClosedDB(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	db.Close()
+
+	// Used to panic.
+	if _, err := Authenticate(db, "foo", "bar"); err == nil {
+		t.Fatal("expected authentication to fail")
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Single-use password reset tokens.
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Lifetime of password reset tokens.
+const (
+	DefaultResetTokenAge = time.Hour
+	MaxResetTokenAge     = 7 * 24 * time.Hour
+)
+
+var (
+	ErrUnknownUser          = errors.New("user not found")
+	errInvalidResetToken    = errors.New("invalid or expired password reset token")
+	errInvalidResetTokenTTL = errors.New("invalid password reset token lifetime")
+)
+
+// Creates a password reset token for the user that's valid for ttl.
+// Previous reset tokens of the user stop working.
+// Returns the token, which can't be retrieved again.
+func CreateResetToken(db *sql.DB, username string, ttl time.Duration) (string, error) {
+	if ttl <= 0 || ttl > MaxResetTokenAge {
+		return "", fmt.Errorf("failed to create password reset token: %w", errInvalidResetTokenTTL)
+	}
+
+	var userID int
+	query := `SELECT id FROM user WHERE username = ?`
+	err := db.QueryRow(query, username).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("failed to create password reset token: %w", ErrUnknownUser)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	query = `
+		DELETE FROM password_reset_token
+		WHERE user_id = ? OR expires <= unixepoch('now')
+	`
+	if _, err := tx.Exec(query, userID); err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+
+	query = `
+		INSERT INTO password_reset_token (token_hash, user_id, expires)
+		VALUES (?, ?, ?)
+	`
+	expires := time.Now().Add(ttl).Unix()
+	if _, err := tx.Exec(query, hashToken(token), userID, expires); err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to create password reset token: %w", err)
+	}
+	return token, nil
+}
+
+// Checks if the reset token is valid without using it up.
+// Returns the username of the token's owner.
+func CheckResetToken(db *sql.DB, token string) (string, error) {
+	var username string
+	query := `
+		SELECT username FROM password_reset_token JOIN user ON user_id = id
+		WHERE token_hash = ? AND expires > unixepoch('now')
+	`
+	err := db.QueryRow(query, hashToken(token)).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", errInvalidResetToken
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to check password reset token: %w", err)
+	}
+	return username, nil
+}
+
+// Sets the user's new password and uses up the reset token.
+// Also signs out the user's sessions, deletes their access tokens and clears
+// their failed sign-in attempts.
+// Everything is done in one transaction, so the token still works if any step
+// fails.
+// Returns the ID of the token's owner.
+func ResetPassword(db *sql.DB, token, password string) (int, error) {
+	if err := (PasswordPolicy{}).Check(password); err != nil {
+		return 0, err
+	}
+	// Hash before starting the transaction, because it's slow.
+	hash, err := saltHashPassword(password)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var userID int
+	query := `
+		DELETE FROM password_reset_token
+		WHERE token_hash = ? AND expires > unixepoch('now')
+		RETURNING user_id
+	`
+	err = tx.QueryRow(query, hashToken(token)).Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, errInvalidResetToken
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	query = `UPDATE user SET password = ? WHERE id = ?`
+	if _, err := tx.Exec(query, hash, userID); err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	// Sign-in attempts are cleared so that the user isn't locked out by the
+	// attempts that made them forget their password.
+	query = `
+		DELETE FROM login_attempt
+		WHERE action = ? AND username = (SELECT username FROM user WHERE id = ?)
+	`
+	if _, err := tx.Exec(query, ActionSignIn, userID); err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+
+	// Whoever knew the old password might still have a session or an access
+	// token.
+	for _, query := range []string{
+		`DELETE FROM user_session WHERE user_id = ?`,
+		`DELETE FROM access_token WHERE user_id = ?`,
+	} {
+		if _, err := tx.Exec(query, userID); err != nil {
+			return 0, fmt.Errorf("failed to reset password: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to reset password: %w", err)
+	}
+	return userID, nil
+}
+
+// Checks if the error is caused by an invalid or expired reset token.
+func IsInvalidResetToken(err error) bool {
+	return errors.Is(err, errInvalidResetToken)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestResetToken(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	id, err := Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	token, err := CreateResetToken(db, "foo", time.Hour)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	username, err := CheckResetToken(db, token)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if username != "foo" {
+		t.Fatal("expected token to belong to foo:", username)
+	}
+
+	// Invalid passwords don't use up the token.
+	if _, err := ResetPassword(db, token, ""); err == nil {
+		t.Fatal("expected empty password to be rejected")
+	}
+
+	userID, err := ResetPassword(db, token, "new password")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if userID != id {
+		t.Fatal("expected token to belong to user:", id, userID)
+	}
+	if _, err := Authenticate(db, "foo", "new password"); err != nil {
+		t.Fatal("expected password to be changed:", err)
+	}
+
+	// Tokens can only be used once.
+	if _, err := ResetPassword(db, token, "another password"); !IsInvalidResetToken(err) {
+		t.Fatal("expected used token to be invalid:", err)
+	}
+}
+
+func TestResetPasswordClearsCredentials(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	id, err := Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := CreateAccessToken(db, id, "test"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	query := `INSERT INTO user_session (session_id, user_id, username) VALUES ('session', ?, 'foo')`
+	if _, err := db.Exec(query, id); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	now := time.Now()
+	for i := 0; i < usernameBackoff.free; i++ {
+		if _, _, err := TakeAttempt(db, "10.0.0.1", "foo", ActionSignIn, now); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	token, err := CreateResetToken(db, "foo", time.Hour)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := ResetPassword(db, token, "new password"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	for _, table := range []string{"user_session", "access_token"} {
+		var count int
+		query := fmt.Sprintf(`SELECT count(*) FROM %v WHERE user_id = ?`, table)
+		if err := db.QueryRow(query, id).Scan(&count); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		if count != 0 {
+			t.Fatalf("expected %v rows to be deleted, got %v", table, count)
+		}
+	}
+
+	_, wait, err := TakeAttempt(db, "10.0.0.2", "foo", ActionSignIn, now)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if wait > 0 {
+		t.Fatal("expected failed sign-ins to be cleared:", wait)
+	}
+}
+
+func TestResetTokenReplacesOldTokens(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	old, err := CreateResetToken(db, "foo", time.Hour)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := CreateResetToken(db, "foo", time.Hour); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := CheckResetToken(db, old); !IsInvalidResetToken(err) {
+		t.Fatal("expected old token to be invalid:", err)
+	}
+}
+
+func TestExpiredResetToken(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	token, err := CreateResetToken(db, "foo", time.Hour)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	query := `UPDATE password_reset_token SET expires = unixepoch('now') - 1`
+	if _, err := db.Exec(query); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := ResetPassword(db, token, "new password"); !IsInvalidResetToken(err) {
+		t.Fatal("expected expired token to be invalid:", err)
+	}
+}
+
+func TestCreateResetTokenUnknownUser(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if _, err := CreateResetToken(db, "foo", time.Hour); !errors.Is(err, ErrUnknownUser) {
+		t.Fatal("expected unknown user error:", err)
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Exponential backoff for sign-in and registration attempts.
+package auth
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Actions in the `login_attempt` table.
+const (
+	ActionSignIn   = "sign-in"
+	ActionRegister = "register"
+)
+
+// Attempts older than this are forgotten.
+const attemptWindow = 24 * time.Hour
+
+// Backoff policy for a kind of client key.
+type backoffPolicy struct {
+	free     int // Number of attempts allowed without delay
+	base     time.Duration
+	maxDelay time.Duration
+}
+
+var (
+	// IP addresses may be shared by many users (e.g. NAT), so they get more
+	// attempts than usernames.
+	ipBackoff       = backoffPolicy{free: 10, base: time.Second, maxDelay: 15 * time.Minute}
+	usernameBackoff = backoffPolicy{free: 3, base: time.Second, maxDelay: 15 * time.Minute}
+)
+
+// Returns delay required after n attempts.
+func (p backoffPolicy) delay(n int) time.Duration {
+	if n < p.free {
+		return 0
+	}
+	delay := p.base
+	for i := p.free; i < n && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.maxDelay {
+		return p.maxDelay
+	}
+	return delay
+}
+
+// Anything that can run queries: `*sql.DB` or `*sql.Tx`.
+type queryRower interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// Returns how long the client has to wait, given the attempts that match the
+// column value.
+// The attempt with ID `exclude` isn't counted.
+func retryAfter(
+	q queryRower,
+	p backoffPolicy,
+	column, value, action string,
+	now time.Time,
+	exclude int64,
+) (time.Duration, error) {
+	query := fmt.Sprintf(`
+		SELECT count(*), coalesce(max(created), 0) FROM login_attempt
+		WHERE %v = ? AND action = ? AND created > ? AND id != ?
+	`, column)
+
+	var n int
+	var last int64
+	since := now.Add(-attemptWindow).Unix()
+	if err := q.QueryRow(query, value, action, since, exclude).Scan(&n, &last); err != nil {
+		return 0, fmt.Errorf("failed to check login attempts: %w", err)
+	}
+
+	wait := time.Unix(last, 0).Add(p.delay(n)).Sub(now)
+	if wait < 0 {
+		return 0, nil
+	}
+	return wait, nil
+}
+
+// Returns how long the client has to wait before it can try the action again.
+// Sign-ins are throttled by IP address and by username, and registrations by
+// IP address only.
+func actionRetryAfter(
+	q queryRower,
+	ip, username, action string,
+	now time.Time,
+	exclude int64,
+) (time.Duration, error) {
+	byIP, err := retryAfter(q, ipBackoff, "ip", ip, action, now, exclude)
+	if err != nil || action != ActionSignIn {
+		return byIP, err
+	}
+	byUsername, err := retryAfter(q, usernameBackoff, "username", username, action, now, exclude)
+	if err != nil {
+		return 0, err
+	}
+	if byIP > byUsername {
+		return byIP, nil
+	}
+	return byUsername, nil
+}
+
+// Records a sign-in or registration attempt, unless the client has to wait
+// first.
+// Returns the ID of the recorded attempt, or how long the client has to wait.
+// The attempt is recorded before the check in the same transaction, so that
+// parallel requests can't all get past the throttle before any of them is
+// recorded.
+// Also forgets old attempts.
+func TakeAttempt(db *sql.DB, ip, username, action string, now time.Time) (int64, time.Duration, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO login_attempt (ip, username, action, created) VALUES (?, ?, ?, ?)`
+	result, err := tx.Exec(query, ip, username, action, now.Unix())
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+
+	wait, err := actionRetryAfter(tx, ip, username, action, now, id)
+	if err != nil {
+		return 0, 0, err
+	}
+	if wait > 0 {
+		// Throttled attempts aren't recorded.
+		return 0, wait, nil
+	}
+
+	query = `DELETE FROM login_attempt WHERE created <= ?`
+	if _, err := tx.Exec(query, now.Add(-attemptWindow).Unix()); err != nil {
+		return 0, 0, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, 0, fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return id, 0, nil
+}
+
+// Forgets an attempt, e.g. a sign-in that turned out to be successful.
+func ForgetAttempt(db *sql.DB, id int64) error {
+	query := `DELETE FROM login_attempt WHERE id = ?`
+	if _, err := db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to forget login attempt: %w", err)
+	}
+	return nil
+}
+
+// Forgets failed sign-in attempts for the username after a successful
+// sign-in.
+// Attempts from the IP address are kept, so that signing in to one account
+// doesn't reset the limit for guessing other accounts' passwords.
+func ClearFailedSignIns(db *sql.DB, username string) error {
+	query := `DELETE FROM login_attempt WHERE username = ? AND action = ?`
+	if _, err := db.Exec(query, username, ActionSignIn); err != nil {
+		return fmt.Errorf("failed to clear login attempts: %w", err)
+	}
+	return nil
+}
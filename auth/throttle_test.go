@@ -0,0 +1,159 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package auth
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoffDelay(t *testing.T) {
+	t.Parallel()
+
+	p := backoffPolicy{free: 3, base: time.Second, maxDelay: time.Minute}
+	cases := map[int]time.Duration{
+		0:   0,
+		2:   0,
+		3:   time.Second,
+		4:   2 * time.Second,
+		6:   8 * time.Second,
+		100: time.Minute,
+	}
+	for n, expected := range cases {
+		if delay := p.delay(n); delay != expected {
+			t.Fatalf("expected delay after %v attempts to be %v, got %v", n, expected, delay)
+		}
+	}
+}
+
+// Takes an attempt and returns how long the client has to wait.
+func takeAttempt(t *testing.T, db *sql.DB, ip, username, action string, now time.Time) time.Duration {
+	t.Helper()
+	_, wait, err := TakeAttempt(db, ip, username, action, now)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	return wait
+}
+
+func TestTakeAttemptSignIn(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	now := time.Now()
+	for i := 0; i < usernameBackoff.free; i++ {
+		if wait := takeAttempt(t, db, "10.0.0.1", "foo", ActionSignIn, now); wait > 0 {
+			t.Fatal("first few attempts shouldn't be throttled:", wait)
+		}
+	}
+
+	// Another IP address is throttled too, because the username is the same.
+	if wait := takeAttempt(t, db, "10.0.0.2", "foo", ActionSignIn, now); wait <= 0 {
+		t.Fatal("expected sign-in to be throttled after repeated failures")
+	}
+
+	// Other usernames aren't affected yet.
+	if wait := takeAttempt(t, db, "10.0.0.2", "bar", ActionSignIn, now); wait > 0 {
+		t.Fatal("expected other usernames not to be throttled:", wait)
+	}
+
+	// Attempts are forgotten after a successful sign-in.
+	if err := ClearFailedSignIns(db, "foo"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if wait := takeAttempt(t, db, "10.0.0.2", "foo", ActionSignIn, now); wait > 0 {
+		t.Fatal("expected throttle to be cleared:", wait)
+	}
+}
+
+func TestTakeAttemptInParallel(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	now := time.Now()
+	n := 4 * usernameBackoff.free
+	waits := make(chan time.Duration, n)
+	errs := make(chan error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, wait, err := TakeAttempt(db, "10.0.0.1", "foo", ActionSignIn, now)
+			waits <- wait
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(waits)
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+	allowed := 0
+	for wait := range waits {
+		if wait == 0 {
+			allowed++
+		}
+	}
+	if allowed != usernameBackoff.free {
+		t.Fatalf("expected %v attempts to get through, got %v", usernameBackoff.free, allowed)
+	}
+}
+
+func TestForgetAttempt(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	now := time.Now()
+	for i := 0; i < 2*usernameBackoff.free; i++ {
+		id, wait, err := TakeAttempt(db, "10.0.0.1", "foo", ActionSignIn, now)
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		if wait > 0 {
+			t.Fatal("forgotten attempts shouldn't count:", wait)
+		}
+		if err := ForgetAttempt(db, id); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+}
+
+func TestTakeAttemptForgetsOldAttempts(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	// Old attempts are spaced out so that they don't get throttled.
+	past := time.Now().Add(-2 * attemptWindow)
+	for i := 0; i < ipBackoff.free+5; i++ {
+		created := past.Add(time.Duration(i) * ipBackoff.maxDelay)
+		if wait := takeAttempt(t, db, "10.0.0.1", "", ActionRegister, created); wait > 0 {
+			t.Fatal("expected spaced out attempts not to be throttled:", wait)
+		}
+	}
+
+	now := time.Now()
+	if wait := takeAttempt(t, db, "10.0.0.1", "", ActionRegister, now); wait > 0 {
+		t.Fatal("old attempts shouldn't count:", wait)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM login_attempt`).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 1 {
+		t.Fatal("expected old attempts to be deleted, got:", count)
+	}
+}
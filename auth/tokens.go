@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Personal access tokens.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Metadata of an access token.
+// Doesn't contain the token itself.
+type AccessToken struct {
+	ID       int
+	Name     string
+	Created  time.Time
+	LastUsed time.Time // Zero if the token hasn't been used yet
+}
+
+// Generates a cryptographically secure random 256-bit token.
+func generateToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// Returns hash of token for storage.
+// Tokens are random enough that they don't need to be salted.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Creates a new access token for the user.
+// Returns the token, which can't be retrieved again.
+func CreateAccessToken(db *sql.DB, userID int, name string) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to create access token: %w", err)
+	}
+
+	query := `
+		INSERT INTO access_token (user_id, name, token_hash)
+		VALUES (?, ?, ?)
+	`
+	if _, err := db.Exec(query, userID, name, hashToken(token)); err != nil {
+		return "", fmt.Errorf("failed to create access token: %w", err)
+	}
+	return token, nil
+}
+
+// Lists user's access tokens, starting with the newest.
+func ListAccessTokens(db *sql.DB, userID int) ([]AccessToken, error) {
+	query := `
+		SELECT id, name, created, coalesce(last_used, 0)
+		FROM access_token
+		WHERE user_id = ?
+		ORDER BY id DESC
+	`
+	rows, err := db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list access tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []AccessToken
+	for rows.Next() {
+		var token AccessToken
+		var created, lastUsed int64
+		if err := rows.Scan(&token.ID, &token.Name, &created, &lastUsed); err != nil {
+			return nil, fmt.Errorf("failed to list access tokens: %w", err)
+		}
+		token.Created = time.Unix(created, 0)
+		if lastUsed > 0 {
+			token.LastUsed = time.Unix(lastUsed, 0)
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens, nil
+}
+
+// Revokes user's access token.
+// Returns an error if the user doesn't have a token with the given ID.
+func RevokeAccessToken(db *sql.DB, userID, tokenID int) error {
+	query := `DELETE FROM access_token WHERE id = ? AND user_id = ?`
+	result, err := db.Exec(query, tokenID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return errors.New("failed to revoke access token: token not found")
+	}
+	return nil
+}
+
+// Validates access token.
+// Returns the ID and username of the token's owner on success.
+func AuthenticateAccessToken(db *sql.DB, token string) (int, string, error) {
+	var userID int
+	var username string
+	query := `
+		SELECT user.id, user.username
+		FROM access_token JOIN user ON (access_token.user_id = user.id)
+		WHERE token_hash = ?
+	`
+	hash := hashToken(token)
+	if err := db.QueryRow(query, hash).Scan(&userID, &username); err != nil {
+		return 0, "", errors.New("unable to authenticate access token")
+	}
+
+	query = `UPDATE access_token SET last_used = unixepoch('now') WHERE token_hash = ?`
+	if _, err := db.Exec(query, hash); err != nil {
+		return 0, "", fmt.Errorf("unable to authenticate access token: %w", err)
+	}
+	return userID, username, nil
+}
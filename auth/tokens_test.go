@@ -0,0 +1,87 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package auth
+
+import (
+	"testing"
+)
+
+func TestAuthenticateAccessToken(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	userID, err := Authenticate(db, "foo", "bar")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	token, err := CreateAccessToken(db, userID, "test")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	id, username, err := AuthenticateAccessToken(db, token)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if id != userID || username != "foo" {
+		t.Fatal("expected token to authenticate its owner:", id, username)
+	}
+
+	tokens, err := ListAccessTokens(db, userID)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(tokens) != 1 || tokens[0].Name != "test" || tokens[0].LastUsed.IsZero() {
+		t.Fatal("expected used token to be listed:", tokens)
+	}
+}
+
+func TestAuthenticateInvalidAccessToken(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if _, _, err := AuthenticateAccessToken(db, "invalid"); err == nil {
+		t.Fatal("authentication should fail if token doesn't exist")
+	}
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	t.Parallel()
+	db := openDB()
+	defer db.Close()
+
+	if err := Register(db, "foo", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := Register(db, "baz", "bar"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	owner, _ := Authenticate(db, "foo", "bar")
+	other, _ := Authenticate(db, "baz", "bar")
+
+	token, err := CreateAccessToken(db, owner, "test")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	tokens, err := ListAccessTokens(db, owner)
+	if err != nil || len(tokens) != 1 {
+		t.Fatal("expected one access token:", tokens, err)
+	}
+
+	if err := RevokeAccessToken(db, other, tokens[0].ID); err == nil {
+		t.Fatal("users shouldn't be able to revoke other users' tokens")
+	}
+	if err := RevokeAccessToken(db, owner, tokens[0].ID); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, _, err := AuthenticateAccessToken(db, token); err == nil {
+		t.Fatal("authentication should fail after token gets revoked")
+	}
+}
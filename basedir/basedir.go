@@ -4,6 +4,7 @@
 package basedir
 
 import (
+	"fmt"
 	"log"
 	"os"
 	"path"
@@ -45,7 +46,11 @@ func xdgStateHome() string {
 }
 
 func initStateDir() error {
-	StateDir = path.Join(xdgStateHome(), "polycloze")
+	return setStateDir(path.Join(xdgStateHome(), "polycloze"))
+}
+
+func setStateDir(dir string) error {
+	StateDir = dir
 	users := path.Join(StateDir, "users")
 
 	if err := os.MkdirAll(users, 0o700); err != nil {
@@ -54,3 +59,17 @@ func initStateDir() error {
 	}
 	return nil
 }
+
+// Overrides data and state directories (e.g. from the config file).
+// Empty paths keep their defaults.
+func Set(dataDir, stateDir string) error {
+	if dataDir != "" {
+		DataDir = dataDir
+	}
+	if stateDir != "" {
+		if err := setStateDir(stateDir); err != nil {
+			return fmt.Errorf("failed to set state directory: %w", err)
+		}
+	}
+	return nil
+}
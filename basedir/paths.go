@@ -25,12 +25,23 @@ func Review(userID int, l1, l2 string) string {
 	return path.Join(User(userID), "reviews", fmt.Sprintf("%s-%s.db", l1, l2))
 }
 
+// Returns path to database of user-defined sentences for the course.
+// l1 and l2: ISO 639-3 code
+func Custom(userID int, l1, l2 string) string {
+	return path.Join(User(userID), "custom", fmt.Sprintf("%s-%s.db", l1, l2))
+}
+
 // Returns path to database for course.
 // l1 and l2 are ISO 639-3 codes.
 func Course(l1, l2 string) string {
 	return path.Join(DataDir, "courses", fmt.Sprintf("%s-%s.db", l1, l2))
 }
 
+// Returns path to directory of audio files generated by the TTS command.
+func TTSCache() string {
+	return path.Join(StateDir, "tts")
+}
+
 func Auth() string {
 	return path.Join(StateDir, "auth.db")
 }
@@ -0,0 +1,112 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Server administration commands.
+package main
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/polycloze/polycloze/auth"
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: admin invite [-state-dir dir] [-uses n] [-expires duration]")
+	fmt.Fprintln(os.Stderr, "       admin reset [-state-dir dir] [-expires duration] -url url username")
+	os.Exit(2)
+}
+
+// Opens auth DB in the state directory.
+// Uses the default state directory if dir is empty.
+func openAuthDB(dir string) *sql.DB {
+	if err := basedir.Set("", dir); err != nil {
+		log.Fatal(err)
+	}
+	db, err := database.OpenAuthDB(basedir.Auth())
+	if err != nil {
+		log.Fatal(err)
+	}
+	return db
+}
+
+// Creates an invite code and prints it.
+func invite(arguments []string) {
+	var stateDir string
+	var uses int
+	var expires time.Duration
+
+	flags := flag.NewFlagSet("invite", flag.ExitOnError)
+	flags.StringVar(&stateDir, "state-dir", "", "state directory of the server (defaults to $XDG_STATE_HOME/polycloze)")
+	flags.IntVar(&uses, "uses", 1, "number of accounts that can be registered with the code")
+	flags.DurationVar(&expires, "expires", 0, "how long the code is valid (e.g. 72h); never expires if zero")
+	_ = flags.Parse(arguments)
+
+	var expiry time.Time
+	if expires > 0 {
+		expiry = time.Now().Add(expires)
+	}
+
+	db := openAuthDB(stateDir)
+	defer db.Close()
+
+	code, err := auth.CreateInviteCode(db, uses, expiry)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println(code)
+}
+
+// Creates a single-use password reset link for the user and prints it.
+func reset(arguments []string) {
+	var stateDir string
+	var expires time.Duration
+	var base string
+
+	flags := flag.NewFlagSet("reset", flag.ExitOnError)
+	flags.StringVar(&stateDir, "state-dir", "", "state directory of the server (defaults to $XDG_STATE_HOME/polycloze)")
+	flags.DurationVar(&expires, "expires", auth.DefaultResetTokenAge, "how long the link is valid")
+	flags.StringVar(&base, "url", "", "public URL of the server (e.g. https://example.com)")
+	_ = flags.Parse(arguments)
+
+	if flags.NArg() != 1 {
+		usage()
+	}
+	// The link is useless to the user if it points somewhere else.
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		log.Fatal("-url: expected public http:// or https:// URL of the server")
+	}
+	username := flags.Arg(0)
+
+	db := openAuthDB(stateDir)
+	defer db.Close()
+
+	token, err := auth.CreateResetToken(db, username, expires)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%v/reset?token=%v\n", strings.TrimSuffix(base, "/"), url.QueryEscape(token))
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+
+	switch os.Args[1] {
+	case "invite":
+		invite(os.Args[2:])
+	case "reset":
+		reset(os.Args[2:])
+	default:
+		usage()
+	}
+}
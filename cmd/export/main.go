@@ -0,0 +1,75 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/replay"
+)
+
+type Args struct {
+	dbFile string
+	format replay.Format
+	course string // l1-l2, optional
+}
+
+func parseArgs() Args {
+	var args Args
+	var format string
+	flag.StringVar(&format, "format", "csv", "export format (csv, jsonl or anki)")
+	flag.StringVar(&args.course, "course", "", "course (e.g. eng-deu) for example sentences in Anki notes")
+	flag.Parse()
+
+	nonFlags := flag.Args()
+	if len(nonFlags) < 1 {
+		log.Fatal("missing arg: path to review DB")
+	}
+	args.dbFile = nonFlags[0]
+
+	var err error
+	args.format, err = replay.ParseFormat(format)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return args
+}
+
+func main() {
+	args := parseArgs()
+
+	db, err := database.OpenReviewDB(args.dbFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	var hooks []database.ConnectionHook
+	if args.course != "" {
+		l1, l2, ok := strings.Cut(args.course, "-")
+		if !ok {
+			log.Fatal("invalid course:", args.course)
+		}
+		hooks = append(hooks, database.AttachCourse(basedir.Course(l1, l2)))
+	}
+
+	con, err := database.NewConnection(db, context.TODO(), hooks...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer con.Close()
+
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	if err := replay.Export(con, w, args.format); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/replay"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/wilson"
+)
+
+type Args struct {
+	dbFile     string
+	scheduler  string
+	thresholds wilson.Thresholds
+}
+
+func parseArgs() Args {
+	args := Args{thresholds: wilson.DefaultThresholds}
+	t := &args.thresholds
+	flag.StringVar(&args.scheduler, "scheduler", rs.DefaultSchedulerName, "review scheduler")
+	flag.Float64Var(&t.EasyZ, "easy-z", t.EasyZ, "z-score of lower bound for lengthening intervals (level estimate and default scheduler)")
+	flag.Float64Var(&t.Easy, "easy", t.Easy, "lengthen interval if lower bound is above this (level estimate and default scheduler)")
+	flag.Float64Var(&t.HardZ, "hard-z", t.HardZ, "z-score of upper bound for shortening intervals (level estimate and default scheduler)")
+	flag.Float64Var(&t.Hard, "hard", t.Hard, "shorten interval if upper bound is below this (level estimate and default scheduler)")
+	flag.Parse()
+
+	nonFlags := flag.Args()
+	if len(nonFlags) < 1 {
+		log.Fatal("missing arg: path to review DB")
+	}
+	args.dbFile = nonFlags[0]
+	return args
+}
+
+func main() {
+	args := parseArgs()
+
+	scheduler, err := rs.Lookup(args.scheduler)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, ok := scheduler.(rs.DefaultScheduler); ok {
+		scheduler = rs.DefaultScheduler{Thresholds: &args.thresholds}
+	}
+
+	db, err := database.OpenReviewDB(args.dbFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	options := replay.RebuildOptions{
+		Scheduler:  scheduler,
+		Thresholds: &args.thresholds,
+	}
+	if err := replay.Rebuild(db, options); err != nil {
+		log.Fatal(err)
+	}
+}
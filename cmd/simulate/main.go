@@ -0,0 +1,162 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/polycloze/polycloze/replay"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/simulation"
+	"github.com/polycloze/polycloze/wilson"
+)
+
+// Flag that can be specified multiple times.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *listFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+type Args struct {
+	logFiles []string
+	configs  []simulation.Config
+	json     bool
+}
+
+// Parses thresholds for the default scheduler in the form "easy,hard".
+func parseThresholds(s string) (wilson.Thresholds, error) {
+	t := wilson.DefaultThresholds
+	easy, hard, ok := strings.Cut(s, ",")
+	if !ok {
+		return t, fmt.Errorf("invalid thresholds: %v", s)
+	}
+
+	var err error
+	if t.Easy, err = strconv.ParseFloat(easy, 64); err != nil {
+		return t, fmt.Errorf("invalid thresholds: %v", s)
+	}
+	if t.Hard, err = strconv.ParseFloat(hard, 64); err != nil {
+		return t, fmt.Errorf("invalid thresholds: %v", s)
+	}
+	return t, nil
+}
+
+func parseArgs() Args {
+	var args Args
+	var schedulers string
+	var thresholds listFlag
+	flag.StringVar(&schedulers, "schedulers", rs.DefaultSchedulerName, "comma-separated list of schedulers to evaluate")
+	flag.Var(&thresholds, "thresholds", "evaluate default scheduler with thresholds \"easy,hard\" (can be repeated)")
+	flag.BoolVar(&args.json, "json", false, "print results as JSON")
+	flag.Parse()
+
+	args.logFiles = flag.Args()
+	if len(args.logFiles) < 1 {
+		log.Fatal("missing arg: path to log files")
+	}
+
+	for _, name := range strings.Split(schedulers, ",") {
+		if name == "" {
+			continue
+		}
+		s, err := rs.Lookup(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		args.configs = append(args.configs, simulation.Config{Name: name, Scheduler: s})
+	}
+
+	for _, value := range thresholds {
+		t, err := parseThresholds(value)
+		if err != nil {
+			log.Fatal(err)
+		}
+		args.configs = append(args.configs, simulation.Config{
+			Name:      fmt.Sprintf("%v(%v)", rs.DefaultSchedulerName, value),
+			Scheduler: rs.DefaultScheduler{Thresholds: &t},
+		})
+	}
+	return args
+}
+
+func readLogs(paths []string) [][]replay.ReviewEvent {
+	var logs [][]replay.ReviewEvent
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		events, err := replay.ReadEvents(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(fmt.Errorf("%v: %w", path, err))
+		}
+		logs = append(logs, events)
+	}
+	return logs
+}
+
+func printTable(reports []simulation.Report) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "scheduler\treviews\tpredicted\tactual\tlog-loss\tmean due/day\tmax due/day")
+	for _, r := range reports {
+		fmt.Fprintf(
+			w,
+			"%v\t%v\t%.4f\t%.4f\t%.4f\t%.2f\t%v\n",
+			r.Name,
+			r.Reviews,
+			r.PredictedRecall,
+			r.ActualRecall,
+			r.LogLoss,
+			r.MeanWorkload,
+			r.MaxWorkload,
+		)
+	}
+	w.Flush()
+
+	for _, r := range reports {
+		fmt.Printf("\n# Retention by interval (%v)\n", r.Name)
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "interval\treviews\tpredicted\tactual")
+		for _, b := range r.Buckets {
+			if b.Reviews == 0 {
+				continue
+			}
+			fmt.Fprintf(w, "%v\t%v\t%.4f\t%.4f\n", b.Interval, b.Reviews, b.Predicted, b.Actual)
+		}
+		w.Flush()
+	}
+}
+
+func main() {
+	args := parseArgs()
+
+	reports, err := simulation.Simulate(args.configs, readLogs(args.logFiles))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if !args.json {
+		printTable(reports)
+		return
+	}
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(reports); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,323 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Server configuration file.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"os"
+	"strings"
+	"time"
+)
+
+// Registration policies.
+const (
+	RegistrationOpen   = "open"   // Anyone can register
+	RegistrationInvite = "invite" // Only users with invite codes can register
+	RegistrationClosed = "closed" // Nobody can register
+)
+
+// Duration that can be written as a string in JSON (e.g. "10m").
+type Duration time.Duration
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+type TLS struct {
+	// Serves HTTPS if both are set.
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+type CORS struct {
+	// Allowed origins. "*" allows every origin.
+	// CORS headers aren't sent if empty.
+	Origins []string `json:"origins"`
+}
+
+type RateLimit struct {
+	// Max number of API requests per client per minute.
+	// Zero means there's no limit.
+	RequestsPerMinute int `json:"requestsPerMinute"`
+
+	// Max number of requests a client can make at once.
+	Burst int `json:"burst"`
+}
+
+type Password struct {
+	// Min number of characters in new passwords.
+	MinLength int `json:"minLength"`
+
+	// File with breached passwords (one per line) that aren't allowed.
+	// Disabled if empty.
+	BreachedList string `json:"breachedList"`
+}
+
+type SMTP struct {
+	// SMTP server (host:port).
+	Addr string `json:"addr"`
+
+	// Doesn't authenticate if empty.
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+type Mail struct {
+	// Sender address (e.g. "polycloze <noreply@example.com>").
+	From string `json:"from"`
+
+	// Writes messages to files in this directory instead of sending them.
+	Dir string `json:"dir"`
+
+	SMTP SMTP `json:"smtp"`
+}
+
+type Log struct {
+	// Log file. Logs are written to stderr if empty.
+	File string `json:"file"`
+
+	// Logs every request if true.
+	Requests bool `json:"requests"`
+}
+
+type TTS struct {
+	// Local TTS command (placeholders: {lang}, {output}).
+	// The text is written to the command's stdin.
+	// Disabled if empty.
+	Command string `json:"command"`
+
+	// Extension of audio files created by the TTS command.
+	Ext string `json:"ext"`
+
+	// Max number of TTS commands to run at the same time.
+	MaxConcurrent int `json:"maxConcurrent"`
+
+	// Max size of cached audio files in bytes.
+	MaxCacheSize int64 `json:"maxCacheSize"`
+}
+
+type Config struct {
+	// Directory of course files. Defaults to $XDG_DATA_HOME/polycloze.
+	DataDir string `json:"dataDir"`
+
+	// Directory of user data. Defaults to $XDG_STATE_HOME/polycloze.
+	StateDir string `json:"stateDir"`
+
+	// Address to listen on (e.g. ":3000", "127.0.0.1:8080").
+	Listen string `json:"listen"`
+
+	// Public URL of the server, used in password reset links.
+	// Reset links can't be created through the API if empty.
+	PublicURL string `json:"publicURL"`
+
+	// Max duration for reading a request, including the body.
+	ReadTimeout Duration `json:"readTimeout"`
+
+	// Max duration before timing out writes of the response.
+	WriteTimeout Duration `json:"writeTimeout"`
+
+	// Max duration for waiting for in-flight requests to finish when shutting
+	// down.
+	ShutdownTimeout Duration `json:"shutdownTimeout"`
+
+	TLS  TLS  `json:"tls"`
+	CORS CORS `json:"cors"`
+
+	// Max size of uploaded files in bytes.
+	MaxUploadSize int64 `json:"maxUploadSize"`
+
+	// Max number of sentences returned by /api/sentences.
+	MaxSentences int `json:"maxSentences"`
+
+	RateLimit RateLimit `json:"rateLimit"`
+
+	// Identify clients by the last entry of the X-Forwarded-For header.
+	// Only enable behind a reverse proxy that sets the header.
+	TrustProxy bool `json:"trustProxy"`
+
+	// Identify clients by a header that the reverse proxy sets to the client's
+	// IP address (e.g. Fly-Client-IP). Takes precedence over trustProxy.
+	ClientIPHeader string `json:"clientIPHeader"`
+
+	// Who can create accounts ("open", "invite" or "closed").
+	Registration string `json:"registration"`
+
+	Password Password `json:"password"`
+
+	// Secret for admin endpoints (X-Admin-Token header).
+	// Admin endpoints are disabled if empty.
+	AdminToken string `json:"adminToken"`
+
+	// Delivers password reset links. Disabled if neither dir nor smtp.addr is
+	// set.
+	Mail Mail `json:"mail"`
+
+	Log Log `json:"log"`
+
+	// Max number of review DBs to keep open.
+	MaxOpenDBs int `json:"maxOpenDBs"`
+
+	// Close review DBs that haven't been used for this long.
+	IdleDBTimeout Duration `json:"idleDBTimeout"`
+
+	// Number of flashcards to generate in the background per user.
+	Prefetch int `json:"prefetch"`
+
+	// Discard prefetched flashcards that are older than this.
+	PrefetchMaxAge Duration `json:"prefetchMaxAge"`
+
+	TTS TTS `json:"tts"`
+}
+
+// Returns default config.
+// Empty directories are filled in by basedir.
+func Default() Config {
+	return Config{
+		Listen:          ":3000",
+		ReadTimeout:     Duration(30 * time.Second),
+		WriteTimeout:    Duration(60 * time.Second),
+		ShutdownTimeout: Duration(30 * time.Second),
+		MaxUploadSize:   8 * 1024 * 1024,
+		MaxSentences:    1000,
+		Registration:    RegistrationOpen,
+		Password:        Password{MinLength: 8},
+		Log:             Log{Requests: true},
+		MaxOpenDBs:      256,
+		IdleDBTimeout:   Duration(10 * time.Minute),
+		Prefetch:        30,
+		PrefetchMaxAge:  Duration(5 * time.Minute),
+		TTS: TTS{
+			Ext:           "wav",
+			MaxConcurrent: 2,
+			MaxCacheSize:  256 * 1024 * 1024,
+		},
+	}
+}
+
+// Reads config file in JSON format.
+// Settings that aren't in the file keep their default values.
+func Load(path string) (Config, error) {
+	config := Default()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&config); err != nil {
+		return config, fmt.Errorf("failed to load config (%v): %w", path, err)
+	}
+	return config, nil
+}
+
+// Checks if the config is valid.
+// Reports every invalid setting at once.
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, a ...any) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, a...))
+		}
+	}
+
+	_, port, err := net.SplitHostPort(c.Listen)
+	check(err == nil && port != "", "listen: expected host:port, got %q", c.Listen)
+	check(
+		c.PublicURL == "" || strings.HasPrefix(c.PublicURL, "http://") || strings.HasPrefix(c.PublicURL, "https://"),
+		"publicURL: expected http:// or https:// URL, got %q",
+		c.PublicURL,
+	)
+	check(c.ReadTimeout > 0, "readTimeout: should be positive")
+	check(c.WriteTimeout > 0, "writeTimeout: should be positive")
+	check(c.ShutdownTimeout > 0, "shutdownTimeout: should be positive")
+	check(
+		(c.TLS.CertFile == "") == (c.TLS.KeyFile == ""),
+		"tls: certFile and keyFile should be set together",
+	)
+	for _, file := range []string{c.TLS.CertFile, c.TLS.KeyFile} {
+		if file != "" {
+			_, err := os.Stat(file)
+			check(err == nil, "tls: %v", err)
+		}
+	}
+	for _, origin := range c.CORS.Origins {
+		check(
+			origin == "*" || strings.HasPrefix(origin, "http://") || strings.HasPrefix(origin, "https://"),
+			"cors: invalid origin %q",
+			origin,
+		)
+	}
+	check(c.MaxUploadSize > 0, "maxUploadSize: should be positive")
+	check(c.MaxSentences > 0, "maxSentences: should be positive")
+	check(c.RateLimit.RequestsPerMinute >= 0, "rateLimit.requestsPerMinute: should not be negative")
+	check(
+		c.RateLimit.RequestsPerMinute == 0 || c.RateLimit.Burst > 0,
+		"rateLimit.burst: should be positive",
+	)
+	check(
+		!strings.ContainsAny(c.ClientIPHeader, " \t\r\n:"),
+		"clientIPHeader: invalid header name %q",
+		c.ClientIPHeader,
+	)
+	check(
+		c.Registration == RegistrationOpen || c.Registration == RegistrationInvite || c.Registration == RegistrationClosed,
+		"registration: expected %q, %q or %q, got %q",
+		RegistrationOpen,
+		RegistrationInvite,
+		RegistrationClosed,
+		c.Registration,
+	)
+	// bcrypt ignores bytes after the 72nd.
+	check(
+		c.Password.MinLength > 0 && c.Password.MinLength <= 72,
+		"password.minLength: should be between 1 and 72",
+	)
+	if c.Password.BreachedList != "" {
+		_, err := os.Stat(c.Password.BreachedList)
+		check(err == nil, "password.breachedList: %v", err)
+	}
+	check(c.AdminToken == "" || len(c.AdminToken) >= 16, "adminToken: should have at least 16 characters")
+	check(c.Mail.Dir == "" || c.Mail.SMTP.Addr == "", "mail: dir and smtp.addr shouldn't be set together")
+	if c.Mail.Dir != "" || c.Mail.SMTP.Addr != "" {
+		_, err := mail.ParseAddress(c.Mail.From)
+		check(err == nil, "mail.from: expected email address, got %q", c.Mail.From)
+	}
+	if c.Mail.SMTP.Addr != "" {
+		_, _, err := net.SplitHostPort(c.Mail.SMTP.Addr)
+		check(err == nil, "mail.smtp.addr: expected host:port, got %q", c.Mail.SMTP.Addr)
+	}
+	check(c.MaxOpenDBs > 0, "maxOpenDBs: should be positive")
+	check(c.IdleDBTimeout > 0, "idleDBTimeout: should be positive")
+	check(c.Prefetch >= 0, "prefetch: should not be negative")
+	check(c.PrefetchMaxAge > 0, "prefetchMaxAge: should be positive")
+	check(c.TTS.Ext != "", "tts.ext: should not be empty")
+	check(c.TTS.MaxConcurrent > 0, "tts.maxConcurrent: should be positive")
+	check(c.TTS.MaxCacheSize > 0, "tts.maxCacheSize: should be positive")
+
+	if len(problems) > 0 {
+		return errors.New("invalid config: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	file := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(file, []byte(content), 0o600); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	return file
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	t.Parallel()
+
+	if err := Default().Validate(); err != nil {
+		t.Fatal("expected default config to be valid:", err)
+	}
+}
+
+func TestLoadKeepsDefaults(t *testing.T) {
+	t.Parallel()
+
+	file := writeConfig(t, `{
+		"listen": "127.0.0.1:8080",
+		"idleDBTimeout": "1m",
+		"cors": {"origins": ["https://example.com"]}
+	}`)
+	c, err := Load(file)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if c.Listen != "127.0.0.1:8080" || time.Duration(c.IdleDBTimeout) != time.Minute {
+		t.Fatal("expected settings in file to be loaded:", c)
+	}
+	if len(c.CORS.Origins) != 1 || c.CORS.Origins[0] != "https://example.com" {
+		t.Fatal("expected CORS origins to be loaded:", c.CORS)
+	}
+	if c.MaxUploadSize != Default().MaxUploadSize || c.Registration != RegistrationOpen {
+		t.Fatal("expected missing settings to keep their default values:", c)
+	}
+}
+
+func TestLoadUnknownField(t *testing.T) {
+	t.Parallel()
+
+	file := writeConfig(t, `{"prot": ":3000"}`)
+	if _, err := Load(file); err == nil {
+		t.Fatal("expected typo in config file to be an error")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	invalid := []func(c *Config){
+		func(c *Config) { c.Listen = "3000" },
+		func(c *Config) { c.TLS.CertFile = "cert.pem" },
+		func(c *Config) { c.CORS.Origins = []string{"example.com"} },
+		func(c *Config) { c.MaxUploadSize = 0 },
+		func(c *Config) { c.RateLimit.RequestsPerMinute = 60 },
+		func(c *Config) { c.Registration = "secret" },
+		func(c *Config) { c.ClientIPHeader = "Fly-Client-IP: 1.2.3.4" },
+		func(c *Config) { c.Password.MinLength = 0 },
+		func(c *Config) { c.PublicURL = "example.com" },
+		func(c *Config) { c.AdminToken = "short" },
+		func(c *Config) { c.Mail.Dir = "mail" },
+		func(c *Config) {
+			c.Mail = Mail{From: "noreply@example.com", Dir: "mail", SMTP: SMTP{Addr: "localhost:25"}}
+		},
+		func(c *Config) { c.Password.BreachedList = "nonexistent.txt" },
+		func(c *Config) { c.IdleDBTimeout = 0 },
+		func(c *Config) { c.PrefetchMaxAge = 0 },
+		func(c *Config) { c.TTS.MaxConcurrent = 0 },
+		func(c *Config) { c.TTS.MaxCacheSize = 0 },
+	}
+	for i, change := range invalid {
+		c := Default()
+		change(&c)
+		if err := c.Validate(); err == nil {
+			t.Fatal("expected config to be invalid:", i)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// User-defined sentences.
+package custom
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/polycloze/polycloze/text"
+)
+
+// Same limit as in the course builder.
+const maxLength = 100
+
+var (
+	ErrEmpty   = errors.New("sentence and translation can't be empty")
+	ErrTooLong = fmt.Errorf("sentence can't be longer than %v characters", maxLength)
+)
+
+type Sentence struct {
+	ID          int       `json:"id"`
+	Text        string    `json:"text"`
+	Translation string    `json:"translation"`
+	Added       time.Time `json:"added"`
+}
+
+// Checks if token is a word.
+func isWord(token string) bool {
+	return strings.IndexFunc(token, unicode.IsLetter) >= 0
+}
+
+// Adds sentence to the custom sentence DB.
+func Add(db *sql.DB, sentence, translation string) (Sentence, error) {
+	sentence = strings.TrimSpace(sentence)
+	translation = strings.TrimSpace(translation)
+	if sentence == "" || translation == "" {
+		return Sentence{}, ErrEmpty
+	}
+	if utf8.RuneCountInString(sentence) > maxLength {
+		return Sentence{}, ErrTooLong
+	}
+
+	tokens := text.Tokenize(sentence)
+	encoded, err := json.Marshal(tokens)
+	if err != nil {
+		return Sentence{}, fmt.Errorf("failed to add sentence: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return Sentence{}, fmt.Errorf("failed to add sentence: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var added int64
+	var id int
+	query := `
+		INSERT INTO sentence (text, tokens, translation) VALUES (?, ?, ?)
+		RETURNING id, added
+	`
+	if err := tx.QueryRow(query, sentence, string(encoded), translation).Scan(&id, &added); err != nil {
+		return Sentence{}, fmt.Errorf("failed to add sentence: %w", err)
+	}
+
+	query = `INSERT OR IGNORE INTO contains (sentence, word) VALUES (?, ?)`
+	for _, token := range tokens {
+		if !isWord(token) {
+			continue
+		}
+		if _, err := tx.Exec(query, id, text.Casefold(token)); err != nil {
+			return Sentence{}, fmt.Errorf("failed to add sentence: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return Sentence{}, fmt.Errorf("failed to add sentence: %w", err)
+	}
+	return Sentence{
+		ID:          id,
+		Text:        sentence,
+		Translation: translation,
+		Added:       time.Unix(added, 0),
+	}, nil
+}
+
+// Lists sentences, starting with the most recently added.
+func List(db *sql.DB) ([]Sentence, error) {
+	query := `SELECT id, text, translation, added FROM sentence ORDER BY id DESC`
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sentences: %w", err)
+	}
+	defer rows.Close()
+
+	// To make sure JSON encoding is not nil:
+	sentences := make([]Sentence, 0)
+	for rows.Next() {
+		var sentence Sentence
+		var added int64
+		err := rows.Scan(&sentence.ID, &sentence.Text, &sentence.Translation, &added)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list sentences: %w", err)
+		}
+		sentence.Added = time.Unix(added, 0)
+		sentences = append(sentences, sentence)
+	}
+	return sentences, nil
+}
+
+// Deletes sentence.
+// Returns an error if the sentence doesn't exist.
+func Delete(db *sql.DB, id int) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to delete sentence: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Foreign key constraints aren't enforced by default.
+	if _, err := tx.Exec(`DELETE FROM contains WHERE sentence = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete sentence: %w", err)
+	}
+	result, err := tx.Exec(`DELETE FROM sentence WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete sentence: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return errors.New("failed to delete sentence: sentence not found")
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package custom
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+// NOTE Caller has to Close the db.
+func customDB() *sql.DB {
+	db, err := database.OpenCustomDB(":memory:")
+	if err != nil {
+		panic(err)
+	}
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func TestAdd(t *testing.T) {
+	t.Parallel()
+	db := customDB()
+	defer db.Close()
+
+	sentence, err := Add(db, " Das Mädchen liest. ", "The girl reads.")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if sentence.Text != "Das Mädchen liest." {
+		t.Fatal("expected sentence to be trimmed:", sentence.Text)
+	}
+
+	var words []string
+	rows, err := db.Query(`SELECT word FROM contains WHERE sentence = ? ORDER BY word`, sentence.ID)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		words = append(words, word)
+	}
+
+	if strings.Join(words, " ") != "das liest mädchen" {
+		t.Fatal("expected casefolded words without punctuation:", words)
+	}
+}
+
+func TestAddInvalid(t *testing.T) {
+	t.Parallel()
+	db := customDB()
+	defer db.Close()
+
+	if _, err := Add(db, "Hallo!", " "); !errors.Is(err, ErrEmpty) {
+		t.Fatal("expected ErrEmpty:", err)
+	}
+	if _, err := Add(db, strings.Repeat("a", maxLength+1), "a"); !errors.Is(err, ErrTooLong) {
+		t.Fatal("expected ErrTooLong:", err)
+	}
+	if _, err := Add(db, "Hallo!", "Hello!"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := Add(db, "Hallo!", "Hi!"); err == nil {
+		t.Fatal("expected duplicate sentence to be rejected")
+	}
+}
+
+func TestListAndDelete(t *testing.T) {
+	t.Parallel()
+	db := customDB()
+	defer db.Close()
+
+	sentences, err := List(db)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if sentences == nil || len(sentences) != 0 {
+		t.Fatal("expected empty non-nil list:", sentences)
+	}
+
+	first, err := Add(db, "Hallo!", "Hello!")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	second, err := Add(db, "Tschüss!", "Bye!")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	sentences, err = List(db)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(sentences) != 2 || sentences[0].ID != second.ID {
+		t.Fatal("expected most recent sentence first:", sentences)
+	}
+
+	if err := Delete(db, first.ID); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := Delete(db, first.ID); err == nil {
+		t.Fatal("expected error when deleting missing sentence")
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM contains WHERE sentence = ?`, first.ID).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 0 {
+		t.Fatal("expected contains rows to be deleted:", count)
+	}
+}
@@ -0,0 +1,34 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// For managing DBs of user-defined sentences.
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/pressly/goose/v3"
+)
+
+// Upgrades custom sentence DB to the latest version.
+func upgradeCustomDB(db *sql.DB) error {
+	if err := goose.Up(db, "migrations/custom"); err != nil {
+		return fmt.Errorf("failed to upgrade custom sentence database: %w", err)
+	}
+	return nil
+}
+
+// Opens database of user-defined sentences.
+// The caller has to Close the db.
+func OpenCustomDB(path string) (*sql.DB, error) {
+	db, err := Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open custom sentence database: %w", err)
+	}
+	if err := upgradeCustomDB(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open custom sentence database: %w", err)
+	}
+	return db, nil
+}
@@ -16,3 +16,19 @@ func AttachCourse(path string) ConnectionHook {
 		},
 	}
 }
+
+// Enter: attach custom sentence database.
+// Exit: detach custom sentence database.
+// Should come after `AttachCourse`, so that unqualified table names still refer
+// to the course DB.
+// The custom sentence DB should already exist (see `OpenCustomDB`).
+func AttachCustom(path string) ConnectionHook {
+	return ConnectionHook{
+		Enter: func(c *Connection) error {
+			return attach(c.con, "custom", path)
+		},
+		Exit: func(c *Connection) error {
+			return detach(c.con, "custom")
+		},
+	}
+}
@@ -0,0 +1,19 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- Single-use password reset tokens issued by the server admin.
+CREATE TABLE password_reset_token (
+	-- SHA-256 hash of the token (hex).
+	token_hash TEXT PRIMARY KEY CHECK(token_hash != ''),
+
+	user_id INTEGER NOT NULL REFERENCES user ON DELETE CASCADE,
+	created INTEGER NOT NULL DEFAULT (unixepoch('now')),
+	expires INTEGER NOT NULL
+);
+
+CREATE INDEX index_password_reset_token_user_id ON password_reset_token (user_id);
+
+-- +goose Down
+DROP INDEX index_password_reset_token_user_id;
+DROP TABLE password_reset_token;
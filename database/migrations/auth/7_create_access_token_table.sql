@@ -0,0 +1,23 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- Personal access tokens for third-party clients.
+CREATE TABLE access_token (
+	id INTEGER PRIMARY KEY,
+	user_id INTEGER NOT NULL REFERENCES user ON DELETE CASCADE,
+	name TEXT NOT NULL DEFAULT '',
+
+	-- SHA-256 hash of the token (hex).
+	-- The token itself is only shown to the user once.
+	token_hash TEXT UNIQUE NOT NULL CHECK(token_hash != ''),
+
+	created INTEGER NOT NULL DEFAULT (unixepoch('now')),
+	last_used INTEGER	-- null if the token hasn't been used yet
+);
+
+CREATE INDEX index_access_token_user_id ON access_token (user_id);
+
+-- +goose Down
+DROP INDEX index_access_token_user_id;
+DROP TABLE access_token;
@@ -0,0 +1,14 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- User agent of the device that last used the session.
+ALTER TABLE user_session ADD COLUMN user_agent TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX index_user_session_user_id ON user_session (user_id);
+CREATE INDEX index_user_session_updated ON user_session (updated);
+
+-- +goose Down
+DROP INDEX index_user_session_updated;
+DROP INDEX index_user_session_user_id;
+ALTER TABLE user_session DROP COLUMN user_agent;
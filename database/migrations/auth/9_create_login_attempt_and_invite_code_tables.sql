@@ -0,0 +1,31 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- Failed sign-in attempts and registration attempts, for throttling clients.
+CREATE TABLE login_attempt (
+	id INTEGER PRIMARY KEY,
+	ip TEXT NOT NULL,
+	username TEXT NOT NULL,
+	action TEXT NOT NULL CHECK (action IN ('sign-in', 'register')),
+	created INTEGER NOT NULL DEFAULT (unixepoch('now'))
+);
+
+CREATE INDEX index_login_attempt_ip ON login_attempt (ip, created);
+CREATE INDEX index_login_attempt_username ON login_attempt (username, created);
+
+-- Invite codes for servers with invite-only registration.
+CREATE TABLE invite_code (
+	-- SHA-256 hash of the code (hex).
+	code_hash TEXT PRIMARY KEY CHECK(code_hash != ''),
+
+	created INTEGER NOT NULL DEFAULT (unixepoch('now')),
+	expires INTEGER,	-- null if the code doesn't expire
+	uses_left INTEGER NOT NULL DEFAULT 1 CHECK(uses_left >= 0)
+);
+
+-- +goose Down
+DROP TABLE invite_code;
+DROP INDEX index_login_attempt_username;
+DROP INDEX index_login_attempt_ip;
+DROP TABLE login_attempt;
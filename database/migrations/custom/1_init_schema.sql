@@ -0,0 +1,36 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- +goose StatementBegin
+
+-- User-defined sentences for a course.
+-- Gets attached next to the course DB as `custom`.
+CREATE TABLE sentence (
+	id INTEGER PRIMARY KEY,
+	text TEXT UNIQUE NOT NULL,
+	tokens TEXT NOT NULL,	-- JSON array of strings
+	translation TEXT NOT NULL,
+	added INTEGER NOT NULL DEFAULT (unixepoch('now'))
+);
+
+-- Unlike in the course DB, words are stored as casefolded text, because they
+-- may not be in the course.
+CREATE TABLE contains (
+	sentence INTEGER NOT NULL REFERENCES sentence ON DELETE CASCADE,
+	word TEXT NOT NULL,
+	UNIQUE (sentence, word)
+);
+
+CREATE INDEX index_contains_word ON contains (word);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP INDEX index_contains_word;
+DROP TABLE contains;
+DROP TABLE sentence;
+
+-- +goose StatementEnd
@@ -0,0 +1,61 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- +goose StatementBegin
+
+-- Grade of the most recent review (1: again, 2: hard, 3: good, 4: easy).
+-- Null if unknown.
+ALTER TABLE review ADD COLUMN grade INTEGER CHECK (grade BETWEEN 1 AND 4);
+ALTER TABLE history ADD COLUMN grade INTEGER CHECK (grade BETWEEN 1 AND 4);
+
+-- Copy grades into the history table.
+-- (Replaces triggers in `12_create_review_history.sql`.)
+DROP TRIGGER trigger_history_after_insert_on_review;
+DROP TRIGGER trigger_history_after_update_of_reviewed_on_review;
+
+CREATE TRIGGER trigger_history_after_insert_on_review
+AFTER INSERT ON review
+FOR EACH ROW
+	BEGIN
+		INSERT INTO history (word, reviewed, interval_before, interval_after, grade)
+		VALUES (NEW.item, NEW.reviewed, NULL, NEW.interval, NEW.grade);
+	END;
+
+CREATE TRIGGER trigger_history_after_update_of_reviewed_on_review
+AFTER UPDATE OF reviewed ON review
+FOR EACH ROW
+	BEGIN
+		INSERT INTO history (word, reviewed, interval_before, interval_after, grade)
+		VALUES (NEW.item, NEW.reviewed, OLD.interval, NEW.interval, NEW.grade);
+	END;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+
+DROP TRIGGER trigger_history_after_insert_on_review;
+DROP TRIGGER trigger_history_after_update_of_reviewed_on_review;
+
+-- (Copied from `12_create_review_history.sql`)
+CREATE TRIGGER trigger_history_after_insert_on_review
+AFTER INSERT ON review
+FOR EACH ROW
+	BEGIN
+		INSERT INTO history (word, reviewed, interval_before, interval_after)
+		VALUES (NEW.item, NEW.reviewed, NULL, NEW.interval);
+	END;
+
+CREATE TRIGGER trigger_history_after_update_of_reviewed_on_review
+AFTER UPDATE OF reviewed ON review
+FOR EACH ROW
+	BEGIN
+		INSERT INTO history (word, reviewed, interval_before, interval_after)
+		VALUES (NEW.item, NEW.reviewed, OLD.interval, NEW.interval);
+	END;
+
+ALTER TABLE history DROP COLUMN grade;
+ALTER TABLE review DROP COLUMN grade;
+
+-- +goose StatementEnd
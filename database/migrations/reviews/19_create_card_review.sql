@@ -0,0 +1,66 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- +goose StatementBegin
+
+-- Review tracks of card kinds other than cloze, which uses the `review` table.
+-- Each kind is scheduled separately, so that recognising a word and producing
+-- it have their own intervals.
+CREATE TABLE card_review (
+	kind TEXT NOT NULL,
+	item TEXT NOT NULL,
+	learned INTEGER NOT NULL,
+	reviewed INTEGER NOT NULL,
+	interval INTEGER NOT NULL,	-- # of hours
+	grade INTEGER CHECK (grade BETWEEN 1 AND 4),
+	due INTEGER NOT NULL GENERATED ALWAYS AS (reviewed + 3600*interval) VIRTUAL,
+	correct BOOLEAN GENERATED ALWAYS AS (interval > 0) VIRTUAL,
+	PRIMARY KEY (kind, item)
+);
+
+CREATE INDEX index_card_review_kind_due ON card_review (kind, due);
+
+-- Append-only history of card reviews (see `history`).
+CREATE TABLE card_history (
+	kind TEXT NOT NULL,
+	item TEXT NOT NULL,
+	reviewed INTEGER NOT NULL,
+	interval_before INTEGER,
+	interval_after INTEGER NOT NULL,
+	grade INTEGER CHECK (grade BETWEEN 1 AND 4)
+);
+
+CREATE TRIGGER trigger_card_history_after_insert_on_card_review
+AFTER INSERT ON card_review
+FOR EACH ROW
+	BEGIN
+		INSERT INTO card_history (kind, item, reviewed, interval_before, interval_after, grade)
+		VALUES (NEW.kind, NEW.item, NEW.reviewed, NULL, NEW.interval, NEW.grade);
+	END;
+
+CREATE TRIGGER trigger_card_history_after_update_of_reviewed_on_card_review
+AFTER UPDATE OF reviewed ON card_review
+FOR EACH ROW
+	BEGIN
+		INSERT INTO card_history (kind, item, reviewed, interval_before, interval_after, grade)
+		VALUES (NEW.kind, NEW.item, NEW.reviewed, OLD.interval, NEW.interval, NEW.grade);
+	END;
+
+CREATE TRIGGER trigger_card_history_after_delete_on_card_review
+AFTER DELETE ON card_review
+FOR EACH ROW
+	BEGIN
+		DELETE FROM card_history WHERE kind = OLD.kind AND item = OLD.item;
+	END;
+
+-- +goose StatementEnd
+
+-- +goose Down
+
+DROP TRIGGER trigger_card_history_after_delete_on_card_review;
+DROP TRIGGER trigger_card_history_after_update_of_reviewed_on_card_review;
+DROP TRIGGER trigger_card_history_after_insert_on_card_review;
+DROP TABLE card_history;
+DROP INDEX index_card_review_kind_due;
+DROP TABLE card_review;
@@ -0,0 +1,23 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- +goose StatementBegin
+
+-- User-assigned state of review items (e.g. suspended leeches).
+-- Items without a row are scheduled normally.
+CREATE TABLE word_state (
+	item TEXT PRIMARY KEY,
+	state TEXT NOT NULL,
+	updated INTEGER NOT NULL DEFAULT (unixepoch('now'))
+);
+
+-- For finding consecutive failures of an item.
+CREATE INDEX index_history_word ON history (word, reviewed);
+
+-- +goose StatementEnd
+
+-- +goose Down
+
+DROP INDEX index_history_word;
+DROP TABLE word_state;
@@ -0,0 +1,29 @@
+-- Copyright (c) 2022 Levi Gruspe
+-- License: MIT, or AGPLv3 or later
+
+-- +goose Up
+-- +goose StatementBegin
+
+-- Buried items are hidden until the given time.
+ALTER TABLE word_state ADD COLUMN buried_until INTEGER;
+
+-- Known items are hidden until `known_until`.
+-- Marking an item as known doesn't touch the `review` table, so that it doesn't
+-- show up in the review history.
+ALTER TABLE word_state ADD COLUMN known_until INTEGER;
+
+-- Items that shouldn't be scheduled right now, whether as reviews or as new
+-- words.
+CREATE VIEW hidden_item AS
+SELECT item FROM word_state
+WHERE state = 'suspended'
+	OR (state = 'buried' AND buried_until > unixepoch('now'))
+	OR (state = 'known' AND known_until > unixepoch('now'));
+
+-- +goose StatementEnd
+
+-- +goose Down
+
+DROP VIEW hidden_item;
+ALTER TABLE word_state DROP COLUMN known_until;
+ALTER TABLE word_state DROP COLUMN buried_until;
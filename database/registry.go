@@ -0,0 +1,360 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Registry of long-lived review DB handles.
+package database
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+var ErrRegistryClosed = errors.New("registry is closed")
+
+// Database to attach to every connection of a registry handle.
+type Attachment struct {
+	Name string
+	Path string
+}
+
+// Opens connections to a DB file and attaches other DBs to every new
+// connection.
+type attachConnector struct {
+	path   string
+	driver *sqlite3.SQLiteDriver
+}
+
+func (c attachConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.driver.Open(c.path)
+}
+
+func (c attachConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+// Opens review DB with attached DBs.
+// Unlike connection hooks, attached DBs stay attached for the lifetime of the
+// connection, so the returned DB can be shared between requests.
+func openAttached(path string, attachments []Attachment) (*sql.DB, error) {
+	// Run migrations without attached DBs, so that table names in migration
+	// scripts can't refer to tables in other DBs.
+	db, err := OpenReviewDB(path)
+	if err != nil {
+		return nil, err
+	}
+	db.Close()
+
+	drv := &sqlite3.SQLiteDriver{
+		ConnectHook: func(c *sqlite3.SQLiteConn) error {
+			for _, a := range attachments {
+				query := `attach database ? as ?`
+				if _, err := c.Exec(query, []driver.Value{a.Path, a.Name}); err != nil {
+					return fmt.Errorf("failed to attach %v database: %w", a.Name, err)
+				}
+			}
+			return nil
+		},
+	}
+	return sql.OpenDB(attachConnector{path: path, driver: drv}), nil
+}
+
+type registryEntry struct {
+	key         string
+	path        string
+	attachments []Attachment
+	db          *sql.DB
+
+	refs     int
+	lastUsed time.Time
+
+	// Evicted entries are closed as soon as they're no longer in use.
+	evicted bool
+}
+
+// Returns true if the entry uses the file.
+func (e *registryEntry) uses(path string) bool {
+	if e.path == path {
+		return true
+	}
+	for _, a := range e.attachments {
+		if a.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// Handle that's still being opened.
+// Other callers that want the same handle wait for it instead of opening a
+// duplicate.
+type pendingOpen struct {
+	entry *registryEntry
+	done  chan struct{}
+	err   error
+}
+
+// Keeps migrated review DB handles open between requests.
+// Handles are evicted when there are too many of them (least recently used
+// first), or when they haven't been used in a while.
+type Registry struct {
+	capacity int
+	idle     time.Duration
+
+	// Opens handles. Replaced in tests.
+	open func(path string, attachments []Attachment) (*sql.DB, error)
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	lru     *list.List // Most recently used in front.
+	opening map[string]*pendingOpen
+	closed  bool
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// Creates registry that keeps at most capacity handles open.
+// Unused handles get closed after being idle for the given duration.
+// The caller has to Close the registry.
+func NewRegistry(capacity int, idle time.Duration) *Registry {
+	if capacity < 1 {
+		capacity = 1
+	}
+	r := &Registry{
+		capacity: capacity,
+		idle:     idle,
+		open:     openAttached,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+		opening:  make(map[string]*pendingOpen),
+		done:     make(chan struct{}),
+	}
+
+	if idle > 0 {
+		r.wg.Add(1)
+		go r.janitor()
+	}
+	return r
+}
+
+// Periodically evicts idle handles.
+func (r *Registry) janitor() {
+	defer r.wg.Done()
+
+	ticker := time.NewTicker(r.idle / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-ticker.C:
+			r.evictIdle(now)
+		}
+	}
+}
+
+// Returns registry key for the DB and attachments.
+func registryKey(path string, attachments []Attachment) string {
+	var sb strings.Builder
+	sb.WriteString(path)
+	for _, a := range attachments {
+		fmt.Fprintf(&sb, "\x00%v=%v", a.Name, a.Path)
+	}
+	return sb.String()
+}
+
+// Returns review DB handle with the attached DBs.
+// Opens and migrates the review DB if it isn't open yet.
+// The caller must call release after using the DB, and must not Close it.
+func (r *Registry) Acquire(path string, attachments ...Attachment) (*sql.DB, func(), error) {
+	key := registryKey(path, attachments)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for {
+		if r.closed {
+			return nil, nil, ErrRegistryClosed
+		}
+		if element, ok := r.entries[key]; ok {
+			r.lru.MoveToFront(element)
+			db, release := r.ref(element.Value.(*registryEntry))
+			return db, release, nil
+		}
+
+		p, ok := r.opening[key]
+		if !ok {
+			break
+		}
+
+		// Wait for the handle that's being opened by another caller.
+		r.mu.Unlock()
+		<-p.done
+		r.mu.Lock()
+		if p.err != nil {
+			return nil, nil, p.err
+		}
+	}
+
+	// Open without holding the lock, because migrations can be slow, and
+	// requests for other DBs shouldn't have to wait.
+	p := &pendingOpen{
+		entry: &registryEntry{
+			key:         key,
+			path:        path,
+			attachments: attachments,
+		},
+		done: make(chan struct{}),
+	}
+	r.opening[key] = p
+	r.mu.Unlock()
+	db, err := r.open(path, attachments)
+	r.mu.Lock()
+
+	delete(r.opening, key)
+	p.err = err
+	close(p.done)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	entry := p.entry
+	entry.db = db
+	if r.closed {
+		db.Close()
+		return nil, nil, ErrRegistryClosed
+	}
+
+	// Handles invalidated while being opened are closed after release.
+	if !entry.evicted {
+		r.entries[key] = r.lru.PushFront(entry)
+		r.evictExcess()
+	}
+	db, release := r.ref(entry)
+	return db, release, nil
+}
+
+// Takes a reference to the entry's DB.
+// The caller must hold the lock.
+func (r *Registry) ref(entry *registryEntry) (*sql.DB, func()) {
+	entry.refs++
+	entry.lastUsed = time.Now()
+
+	var once sync.Once
+	release := func() {
+		once.Do(func() { r.release(entry) })
+	}
+	return entry.db, release
+}
+
+func (r *Registry) release(entry *registryEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry.refs--
+	entry.lastUsed = time.Now()
+	if entry.evicted && entry.refs == 0 {
+		entry.db.Close()
+	}
+}
+
+// Removes entry from the registry.
+// The DB gets closed now if it's not in use, or after the last release.
+// The caller must hold the lock.
+func (r *Registry) evict(element *list.Element) {
+	entry := element.Value.(*registryEntry)
+	r.lru.Remove(element)
+	delete(r.entries, entry.key)
+
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.db.Close()
+	}
+}
+
+// Evicts least recently used handles that aren't in use until the registry is
+// within capacity.
+// The caller must hold the lock.
+func (r *Registry) evictExcess() {
+	element := r.lru.Back()
+	for r.lru.Len() > r.capacity && element != nil {
+		prev := element.Prev()
+		if element.Value.(*registryEntry).refs == 0 {
+			r.evict(element)
+		}
+		element = prev
+	}
+}
+
+// Evicts handles that haven't been used since before now - idle.
+func (r *Registry) evictIdle(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element := r.lru.Back()
+	for element != nil {
+		prev := element.Prev()
+		entry := element.Value.(*registryEntry)
+		if entry.refs == 0 && now.Sub(entry.lastUsed) >= r.idle {
+			r.evict(element)
+		}
+		element = prev
+	}
+}
+
+// Evicts all handles that use the DB file.
+// Should be called before the file gets deleted or replaced.
+func (r *Registry) Invalidate(path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	element := r.lru.Back()
+	for element != nil {
+		prev := element.Prev()
+		if element.Value.(*registryEntry).uses(path) {
+			r.evict(element)
+		}
+		element = prev
+	}
+	for _, p := range r.opening {
+		if p.entry.uses(path) {
+			p.entry.evicted = true
+		}
+	}
+}
+
+// Returns number of open handles.
+func (r *Registry) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lru.Len()
+}
+
+// Closes all handles and stops the janitor.
+// Handles that are still in use get closed when they're released.
+func (r *Registry) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	for r.lru.Len() > 0 {
+		r.evict(r.lru.Back())
+	}
+	r.mu.Unlock()
+
+	close(r.done)
+	r.wg.Wait()
+	return nil
+}
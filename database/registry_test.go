@@ -0,0 +1,209 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Creates DB file with a single table.
+func createAttachment(t *testing.T, dir string) Attachment {
+	path := filepath.Join(dir, "course.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(`CREATE TABLE word (id INTEGER PRIMARY KEY, word TEXT)`); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	return Attachment{Name: "course", Path: path}
+}
+
+func TestRegistryAcquire(t *testing.T) {
+	// Handles should be reused and have the attached DBs on every connection.
+	t.Parallel()
+	dir := t.TempDir()
+	course := createAttachment(t, dir)
+
+	registry := NewRegistry(4, 0)
+	defer registry.Close()
+
+	path := filepath.Join(dir, "review.db")
+	a, releaseA, err := registry.Acquire(path, course)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer releaseA()
+
+	b, releaseB, err := registry.Acquire(path, course)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer releaseB()
+
+	if a != b || registry.Len() != 1 {
+		t.Fatal("expected handle to be reused")
+	}
+
+	// Hold a connection, so that the next query needs another one.
+	con, err := a.Conn(context.Background())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	var count int
+	if err := a.QueryRow(`SELECT count(*) FROM course.word JOIN review ON (word = item)`).Scan(&count); err != nil {
+		t.Fatal("expected course DB to be attached to review DB:", err)
+	}
+}
+
+func TestRegistryEvictLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	registry := NewRegistry(2, 0)
+	defer registry.Close()
+
+	var dbs []string
+	for _, name := range []string{"a.db", "b.db", "c.db"} {
+		path := filepath.Join(dir, name)
+		dbs = append(dbs, path)
+
+		_, release, err := registry.Acquire(path)
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		release()
+	}
+
+	if registry.Len() != 2 {
+		t.Fatal("expected registry to stay within capacity:", registry.Len())
+	}
+
+	registry.mu.Lock()
+	_, found := registry.entries[registryKey(dbs[0], nil)]
+	registry.mu.Unlock()
+	if found {
+		t.Fatal("expected least recently used handle to be evicted")
+	}
+}
+
+func TestRegistryKeepsHandlesInUse(t *testing.T) {
+	// Handles shouldn't be closed while they're in use.
+	t.Parallel()
+	dir := t.TempDir()
+
+	registry := NewRegistry(1, 0)
+
+	db, release, err := registry.Acquire(filepath.Join(dir, "a.db"))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	registry.Invalidate(filepath.Join(dir, "a.db"))
+	if err := db.Ping(); err != nil {
+		t.Fatal("expected invalidated handle to stay open until released:", err)
+	}
+
+	registry.Close()
+	if err := db.Ping(); err != nil {
+		t.Fatal("expected handle to stay open until released:", err)
+	}
+
+	release()
+	if err := db.Ping(); err == nil {
+		t.Fatal("expected handle to be closed after release")
+	}
+
+	if _, _, err := registry.Acquire(filepath.Join(dir, "a.db")); !errors.Is(err, ErrRegistryClosed) {
+		t.Fatal("expected ErrRegistryClosed:", err)
+	}
+}
+
+func TestRegistryEvictIdle(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	registry := NewRegistry(4, time.Minute)
+	defer registry.Close()
+
+	_, release, err := registry.Acquire(filepath.Join(dir, "a.db"))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	release()
+
+	registry.evictIdle(time.Now())
+	if registry.Len() != 1 {
+		t.Fatal("expected recently used handle to stay open")
+	}
+
+	registry.evictIdle(time.Now().Add(time.Hour))
+	if registry.Len() != 0 {
+		t.Fatal("expected idle handle to be evicted")
+	}
+}
+
+func TestRegistryOpensWithoutBlockingOtherDBs(t *testing.T) {
+	// A slow open shouldn't block other DBs, and concurrent callers for the
+	// same DB should share one handle.
+	t.Parallel()
+	dir := t.TempDir()
+	slow := filepath.Join(dir, "slow.db")
+
+	registry := NewRegistry(4, 0)
+	defer registry.Close()
+
+	started := make(chan struct{})
+	unblock := make(chan struct{})
+	registry.open = func(path string, attachments []Attachment) (*sql.DB, error) {
+		if path == slow {
+			close(started)
+			<-unblock
+		}
+		return openAttached(path, attachments)
+	}
+
+	type result struct {
+		db      *sql.DB
+		release func()
+		err     error
+	}
+	results := make(chan result, 2)
+	acquire := func() {
+		db, release, err := registry.Acquire(slow)
+		results <- result{db, release, err}
+	}
+	go acquire()
+	<-started
+	go acquire()
+
+	_, release, err := registry.Acquire(filepath.Join(dir, "fast.db"))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	release()
+
+	close(unblock)
+	a, b := <-results, <-results
+	if a.err != nil || b.err != nil {
+		t.Fatal("expected err to be nil:", a.err, b.err)
+	}
+	defer a.release()
+	defer b.release()
+	if a.db != b.db {
+		t.Fatal("expected concurrent callers to share the handle")
+	}
+	if n := registry.Len(); n != 2 {
+		t.Fatal("expected two open handles:", n)
+	}
+}
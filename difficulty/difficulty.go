@@ -18,6 +18,7 @@ type Difficulty struct {
 }
 
 // Returns min difficulty (frequency class of easiest unseen word).
+// Suspended and buried words are ignored.
 // `Querier` should have access to `review` and `word` tables.
 func minDifficulty[T database.Querier](q T) int {
 	query := `
@@ -25,7 +26,7 @@ func minDifficulty[T database.Querier](q T) int {
 		FROM word
 		WHERE word NOT IN (
 			SELECT item FROM review
-		)
+		) AND word NOT IN (SELECT item FROM hidden_item)
 	`
 	var difficulty int
 	_ = q.QueryRow(query).Scan(&difficulty)
@@ -33,6 +34,7 @@ func minDifficulty[T database.Querier](q T) int {
 }
 
 // Returns max difficulty (frequency class of hardest unseen word).
+// Suspended and buried words are ignored.
 // `Querier` should have access to `review` and `word` tables.
 func maxDifficulty[T database.Querier](q T) int {
 	query := `
@@ -40,7 +42,7 @@ func maxDifficulty[T database.Querier](q T) int {
 		FROM word
 		WHERE word NOT IN (
 			SELECT item FROM review
-		)
+		) AND word NOT IN (SELECT item FROM hidden_item)
 	`
 	var difficulty int
 	_ = q.QueryRow(query).Scan(&difficulty)
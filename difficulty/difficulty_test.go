@@ -143,3 +143,24 @@ func TestGetLatestLevelAboveMinLevel(t *testing.T) {
 		t.Fatal("expected level to be 5:", out)
 	}
 }
+
+func TestMinDifficultyIgnoresSuspendedWords(t *testing.T) {
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	queries := []string{
+		`INSERT INTO word (id, word, frequency_class) VALUES (1, 'foo', 1), (2, 'bar', 2)`,
+		`INSERT INTO word_state (item, state) VALUES ('foo', 'suspended')`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	if value := minDifficulty(db); value != 2 {
+		t.Fatal("expected suspended word to be ignored:", value)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package difficulty
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/polycloze/polycloze/wilson"
+)
+
+// Difficulty tuner.
+// Port of `DifficultyTuner` in `api/js/src/difficulty.ts`, which does the
+// tuning during study sessions.
+// Used to rebuild the estimated level from the review history.
+type Tuner struct {
+	Difficulty
+
+	// Thresholds for changing the level.
+	// Should be the same as the ones used by the client.
+	Thresholds wilson.Thresholds
+}
+
+// Creates tuner initialized with the given difficulty.
+// Fields with invalid values are replaced with defaults, like in the client.
+// A non-positive Max is treated as unbounded.
+func NewTuner(difficulty Difficulty) *Tuner {
+	if difficulty.Min < 0 {
+		difficulty.Min = 0
+	}
+	if difficulty.Max <= 0 {
+		difficulty.Max = math.MaxInt
+	}
+	if difficulty.Correct < 0 {
+		difficulty.Correct = 0
+	}
+	if difficulty.Incorrect < 0 {
+		difficulty.Incorrect = 0
+	}
+	if difficulty.Level < 0 {
+		difficulty.Level = difficulty.Min
+	}
+	return &Tuner{
+		Difficulty: difficulty,
+		Thresholds: wilson.DefaultThresholds,
+	}
+}
+
+// Updates level statistics.
+// Returns true if level changed.
+// Also resets `Correct` and `Incorrect` counters if so.
+// This method should only be called for newly seen words.
+func (t *Tuner) Update(correct bool) bool {
+	if correct {
+		t.Correct++
+	} else {
+		t.Incorrect++
+	}
+
+	level := t.Level
+	if t.Thresholds.IsTooEasy(t.Correct, t.Incorrect) {
+		if level+1 < t.Max {
+			t.Level = level + 1
+		} else {
+			t.Level = t.Max
+		}
+	} else if t.Thresholds.IsTooHard(t.Correct, t.Incorrect) {
+		if level-1 > t.Min {
+			t.Level = level - 1
+		} else {
+			t.Level = t.Min
+		}
+	}
+
+	if level == t.Level {
+		return false
+	}
+	t.Correct = 0
+	t.Incorrect = 0
+	return true
+}
+
+// Updates difficulty table with the time of the estimate.
+// Unlike `Update`, only adds an entry to the estimated level history if the
+// level changed.
+func UpdateAtTx(tx *sql.Tx, difficulty Difficulty, t time.Time) error {
+	query := `
+		INSERT INTO estimated_level (t, v, correct, incorrect)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT DO UPDATE SET
+			t = excluded.t,
+			v = excluded.v,
+			correct = excluded.correct,
+			incorrect = excluded.incorrect
+	`
+	_, err := tx.Exec(
+		query,
+		t.Unix(),
+		difficulty.Level,
+		difficulty.Correct,
+		difficulty.Incorrect,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update difficulty table: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package difficulty
+
+import (
+	"testing"
+)
+
+func TestTunerLevelUp(t *testing.T) {
+	// Level should go up after enough correct answers.
+	t.Parallel()
+
+	tuner := NewTuner(Difficulty{})
+	changed := false
+	for i := 0; i < 20 && !changed; i++ {
+		changed = tuner.Update(true)
+	}
+	if !changed || tuner.Level != 1 {
+		t.Fatal("expected level to go up:", tuner.Difficulty)
+	}
+	if tuner.Correct != 0 || tuner.Incorrect != 0 {
+		t.Fatal("expected counters to be reset:", tuner.Difficulty)
+	}
+}
+
+func TestTunerMin(t *testing.T) {
+	// Level shouldn't go below min.
+	t.Parallel()
+
+	tuner := NewTuner(Difficulty{Level: 3, Min: 3})
+	for i := 0; i < 20; i++ {
+		if tuner.Update(false) {
+			t.Fatal("expected level to stay the same:", tuner.Difficulty)
+		}
+	}
+	if tuner.Level != 3 {
+		t.Fatal("expected level to stay at min:", tuner.Difficulty)
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Alternative answers.
+package flashcards
+
+import (
+	"fmt"
+
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/text"
+)
+
+// Course info needed to generate alternative answers.
+type answerContext struct {
+	lang     string // L2 code
+	variants bool   // Does the course DB have a `variant` table?
+	audio    bool   // Does the course DB have an `audio` table?
+}
+
+// Older course DBs don't have `variant` and `audio` tables, so their existence
+// has to be checked first.
+func newAnswerContext[T database.Querier](q T) (answerContext, error) {
+	var ac answerContext
+
+	query := `SELECT code FROM language WHERE id = 'l2'`
+	if err := q.QueryRow(query).Scan(&ac.lang); err != nil {
+		return ac, fmt.Errorf("failed to get course language: %w", err)
+	}
+
+	var count int
+	query = `SELECT count(*) FROM pragma_table_list WHERE name = 'variant'`
+	if err := q.QueryRow(query).Scan(&count); err != nil {
+		return ac, fmt.Errorf("failed to check variant table: %w", err)
+	}
+	ac.variants = count > 0
+
+	query = `SELECT count(*) FROM pragma_table_list WHERE name = 'audio'`
+	if err := q.QueryRow(query).Scan(&count); err != nil {
+		return ac, fmt.Errorf("failed to check audio table: %w", err)
+	}
+	ac.audio = count > 0
+	return ac, nil
+}
+
+// Gets variants of the word from the course DB.
+func getVariants[T database.Querier](q T, word string) ([]Alternative, error) {
+	query := `
+		SELECT text, correct FROM variant
+		JOIN word ON (variant.word = word.id)
+		WHERE word.word = ?
+	`
+	rows, err := q.Query(query, word)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variants (%v): %w", word, err)
+	}
+	defer rows.Close()
+
+	var variants []Alternative
+	for rows.Next() {
+		var variant Alternative
+		if err := rows.Scan(&variant.Text, &variant.Correct); err != nil {
+			return nil, fmt.Errorf("failed to get variants (%v): %w", word, err)
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// Returns accepted alternatives to the answer.
+// Includes variants from the course DB, and near misses with language-specific
+// folding and without diacritics.
+func getAlternatives[T database.Querier](
+	q T,
+	ac answerContext,
+	answer Answer,
+) ([]Alternative, error) {
+	seen := map[string]bool{answer.Text: true}
+	var alternatives []Alternative
+	add := func(alternative Alternative) {
+		if !seen[alternative.Text] {
+			seen[alternative.Text] = true
+			alternatives = append(alternatives, alternative)
+		}
+	}
+
+	if ac.variants {
+		// The normalized answer may be a lemma, so look up the form that
+		// appears in the sentence.
+		variants, err := getVariants(q, text.Casefold(answer.Text))
+		if err != nil {
+			return nil, err
+		}
+		// Fully correct variants go first, so that they don't get shadowed by
+		// near misses.
+		for _, variant := range variants {
+			if variant.Correct {
+				add(variant)
+			}
+		}
+		for _, variant := range variants {
+			if !variant.Correct {
+				add(variant)
+			}
+		}
+	}
+
+	folded := text.FoldLanguage(ac.lang, answer.Text)
+	add(Alternative{Text: folded})
+	add(Alternative{Text: text.StripDiacritics(answer.Text)})
+	add(Alternative{Text: text.StripDiacritics(folded)})
+	return alternatives, nil
+}
+
+// Adds alternatives to every answer in the item.
+func addAlternatives[T database.Querier](q T, ac answerContext, item *Item) error {
+	for i := range item.Sentence.Parts {
+		answers := item.Sentence.Parts[i].Answers
+		for j := range answers {
+			alternatives, err := getAlternatives(q, ac, answers[j])
+			if err != nil {
+				return err
+			}
+			answers[j].Alternatives = alternatives
+		}
+	}
+	return nil
+}
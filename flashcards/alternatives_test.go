@@ -0,0 +1,97 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package flashcards
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/polycloze/polycloze/utils"
+)
+
+// NOTE Caller should close DB.
+func germanCourse() *sql.DB {
+	db := utils.TestingDatabase()
+	queries := []string{
+		`INSERT INTO language (id, code, name, bcp47) VALUES
+			('l1', 'eng', 'English', 'en'), ('l2', 'deu', 'Deutsch', 'de')`,
+		`INSERT INTO word (id, word, frequency_class) VALUES (1, 'mädchen', 0)`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			panic(err)
+		}
+	}
+	return db
+}
+
+func TestAlternativesWithoutVariantTable(t *testing.T) {
+	// Should still generate near misses if the course has no variant table.
+	t.Parallel()
+	db := germanCourse()
+	defer db.Close()
+
+	ac, err := newAnswerContext(db)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if ac.lang != "deu" || ac.variants {
+		t.Fatal("unexpected answer context:", ac)
+	}
+
+	alternatives, err := getAlternatives(db, ac, Answer{Text: "Mädchen", Normalized: "mädchen"})
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(alternatives) != 2 {
+		t.Fatal("expected two near misses:", alternatives)
+	}
+	for _, alternative := range alternatives {
+		if alternative.Correct {
+			t.Fatal("expected near misses:", alternatives)
+		}
+		if alternative.Text != "Maedchen" && alternative.Text != "Madchen" {
+			t.Fatal("unexpected alternative:", alternative)
+		}
+	}
+}
+
+func TestAlternativesWithVariants(t *testing.T) {
+	t.Parallel()
+	db := germanCourse()
+	defer db.Close()
+
+	queries := []string{
+		`CREATE TABLE variant (word INTEGER, text TEXT, correct INTEGER)`,
+		`INSERT INTO variant (word, text, correct) VALUES
+			(1, 'Maedchen', 1), (1, 'Mädel', 0)`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	ac, err := newAnswerContext(db)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if !ac.variants {
+		t.Fatal("expected variant table to be detected")
+	}
+
+	alternatives, err := getAlternatives(db, ac, Answer{Text: "Mädchen", Normalized: "mädchen"})
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	// The course's variant should take precedence over the near miss.
+	correct := make(map[string]bool)
+	for _, alternative := range alternatives {
+		correct[alternative.Text] = alternative.Correct
+	}
+	if len(alternatives) != 3 || !correct["Maedchen"] || correct["Mädel"] || correct["Madchen"] {
+		t.Fatal("unexpected alternatives:", alternatives)
+	}
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Recorded sentence audio.
+package flashcards
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+// Returns URL of the audio file, which is served through /share.
+func audioURL(name string) string {
+	u := url.URL{Path: path.Join("/share", name)}
+	return u.String()
+}
+
+// Sets item audio if the course has a recording of the sentence.
+// Custom sentences don't have recorded audio.
+func addAudio[T database.Querier](q T, ac answerContext, item *Item) error {
+	if !ac.audio || item.Sentence.Custom {
+		return nil
+	}
+
+	var name string
+	query := `SELECT path FROM audio WHERE sentence = ?`
+	err := q.QueryRow(query, item.Sentence.ID).Scan(&name)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get sentence audio: %w", err)
+	}
+	item.Audio = audioURL(name)
+	return nil
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package flashcards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+func TestAddAudio(t *testing.T) {
+	// Only sentences with recordings should get audio.
+	t.Parallel()
+	db := smallCourse("eins", "zwei")
+	defer db.Close()
+
+	queries := []string{
+		`CREATE TABLE audio (sentence INTEGER PRIMARY KEY, path TEXT NOT NULL)`,
+		`INSERT INTO audio (sentence, path) VALUES (1, 'audio/eng-deu/1.mp3')`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	con, err := database.NewConnection(db, context.Background())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	items := Get(con, 10, pred)
+	if len(items) != 2 {
+		t.Fatal("expected two flashcards:", items)
+	}
+	for _, item := range items {
+		switch item.Sentence.ID {
+		case 1:
+			if item.Audio != "/share/audio/eng-deu/1.mp3" {
+				t.Fatal("expected audio URL:", item.Audio)
+			}
+		default:
+			if item.Audio != "" {
+				t.Fatal("expected no audio:", item.Audio)
+			}
+		}
+	}
+}
+
+func TestAddAudioWithoutTable(t *testing.T) {
+	t.Parallel()
+	db := smallCourse("eins")
+	defer db.Close()
+
+	con, err := database.NewConnection(db, context.Background())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	items := Get(con, 10, pred)
+	if len(items) != 1 || items[0].Audio != "" {
+		t.Fatal("expected flashcard without audio:", items)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"fmt"
 
 	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
 	"github.com/polycloze/polycloze/sentences"
 	"github.com/polycloze/polycloze/translator"
 	"github.com/polycloze/polycloze/word_scheduler"
@@ -18,11 +19,38 @@ type Sentence struct {
 	ID        int    `json:"id"`    // id in database
 	Parts     []Part `json:"parts"` // Odd-numbers parts are blanks
 	TatoebaID int64  `json:"tatoebaID,omitempty"`
+
+	// True if the sentence is a user-defined sentence.
+	Custom bool `json:"custom,omitempty"`
 }
 
 type Item struct {
 	Sentence    Sentence               `json:"sentence"`
 	Translation translator.Translation `json:"translation"`
+
+	// URL of sentence audio, if available.
+	Audio string `json:"audio,omitempty"`
+
+	// Card kind (cloze if empty).
+	Kind rs.Kind `json:"kind,omitempty"`
+
+	// Multiple choice cards: choices for the first blank.
+	Choices []string `json:"choices,omitempty"`
+
+	// Word-order scrambles: non-whitespace tokens of the sentence in random
+	// order.
+	Scrambled []string `json:"scrambled,omitempty"`
+}
+
+// Returns normalized words that are blanked out in the flashcard.
+func (item Item) Words() []string {
+	var words []string
+	for _, part := range item.Sentence.Parts {
+		for _, answer := range part.Answers {
+			words = append(words, answer.Normalized)
+		}
+	}
+	return words
 }
 
 type ItemGenerator struct {
@@ -57,18 +85,150 @@ func generateItem[T database.Querier](q T, word word_scheduler.Word) (Item, erro
 			ID:        sentence.ID,
 			Parts:     getParts(sentence.Tokens, word),
 			TatoebaID: sentence.TatoebaID,
+			Custom:    sentence.Custom,
 		},
 	}, nil
 }
 
 // Creates a cloze item for each word.
 func generateItems(con *database.Connection, words []word_scheduler.Word) []Item {
+	return generateItemsWith(con, words, generateItem[*database.Connection])
+}
+
+// Creates a cloze item for each word using the given item generator.
+func generateItemsWith(
+	con *database.Connection,
+	words []word_scheduler.Word,
+	generate func(*database.Connection, word_scheduler.Word) (Item, error),
+) []Item {
 	// To make sure JSON encoding is not nil:
 	items := make([]Item, 0)
+	ac, err := newAnswerContext(con)
+	if err != nil {
+		return items
+	}
+	for _, word := range words {
+		item, err := generate(con, word)
+		if err != nil {
+			continue
+		}
+		if err := addAlternatives(con, ac, &item); err != nil {
+			continue
+		}
+		if err := addAudio(con, ac, &item); err == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Max number of blanks in a sentence.
+const maxBlanks = 3
+
+// Creates a cloze item for the word that also blanks as many of the other
+// words as possible.
+// Also returns the words that got blanked.
+func generateMultiItem[T database.Querier](
+	q T,
+	word word_scheduler.Word,
+	others []word_scheduler.Word,
+) (Item, []word_scheduler.Word, error) {
+	var item Item
+
+	var candidates []string
+	for _, other := range others {
+		candidates = append(candidates, other.Word)
+	}
+	sentence, err := sentences.PickSentenceCovering(q, word.Word, candidates)
+	if err != nil {
+		return item, nil, err
+	}
+
+	// Prioritize the word the sentence was picked for.
+	// It's guaranteed to be in the sentence, so it's never dropped.
+	words := append([]word_scheduler.Word{word}, others...)
+	parts, blanked := getMultiParts(sentence.Tokens, words)
+	if len(blanked) > maxBlanks {
+		parts, blanked = getMultiParts(sentence.Tokens, pickWords(words, blanked, maxBlanks))
+	}
+	if len(blanked) == 0 {
+		// Fall back to a regular item, which panics if casefold is inconsistent.
+		item, err := generateItem(q, word)
+		return item, []word_scheduler.Word{word}, err
+	}
+
+	translation, err := translator.Translate(q, sentence)
+	if err != nil {
+		// Panic because this shouldn't happen with generated course files.
+		panic(fmt.Errorf("could not translate sentence (%v): %w", sentence, err))
+	}
+	return Item{
+		Translation: translation,
+		Sentence: Sentence{
+			ID:        sentence.ID,
+			Parts:     parts,
+			TatoebaID: sentence.TatoebaID,
+			Custom:    sentence.Custom,
+		},
+	}, blanked, nil
+}
+
+// Returns the first n words (in priority order) that are in the blanked list.
+func pickWords(words, blanked []word_scheduler.Word, n int) []word_scheduler.Word {
+	found := make(map[string]bool)
+	for _, word := range blanked {
+		found[word.Word] = true
+	}
+
+	var picked []word_scheduler.Word
 	for _, word := range words {
-		if item, err := generateItem(con, word); err == nil {
+		if len(picked) >= n {
+			break
+		}
+		if found[word.Word] {
+			picked = append(picked, word)
+		}
+	}
+	return picked
+}
+
+// Creates cloze items with multiple blanks.
+// Every word gets blanked exactly once, unless no sentence can be found for it.
+func generateMultiItems(con *database.Connection, words []word_scheduler.Word) []Item {
+	// To make sure JSON encoding is not nil:
+	items := make([]Item, 0)
+	ac, err := newAnswerContext(con)
+	if err != nil {
+		return items
+	}
+
+	pending := words
+	for len(pending) > 0 {
+		word := pending[0]
+		others := pending[1:]
+
+		item, blanked, err := generateMultiItem(con, word, others)
+		if err == nil {
+			err = addAlternatives(con, ac, &item)
+		}
+		if err == nil {
+			err = addAudio(con, ac, &item)
+		}
+		if err == nil {
 			items = append(items, item)
 		}
+
+		done := map[string]bool{word.Word: true}
+		for _, w := range blanked {
+			done[w.Word] = true
+		}
+		var remaining []word_scheduler.Word
+		for _, other := range others {
+			if !done[other.Word] {
+				remaining = append(remaining, other)
+			}
+		}
+		pending = remaining
 	}
 	return items
 }
@@ -81,9 +241,47 @@ func Get(
 	n int,
 	pred func(word string) bool,
 ) []Item {
-	words, err := word_scheduler.GetWordsWith(con, n, pred)
+	return GetWithQuota(con, n, word_scheduler.Unlimited, pred)
+}
+
+// Like Get, but doesn't return more new words and reviews than the quota
+// allows.
+func GetWithQuota(
+	con *database.Connection,
+	n int,
+	quota word_scheduler.Quota,
+	pred func(word string) bool,
+) []Item {
+	words, err := word_scheduler.GetWordsWithQuota(con, n, quota, pred)
 	if err != nil {
 		return nil
 	}
 	return generateItems(con, words)
 }
+
+// Like Get, but blanks multiple words in the same sentence when possible.
+// Each blank is reviewed separately.
+// n: max number of words to schedule, so fewer than n flashcards may be
+// returned.
+func GetMulti(
+	con *database.Connection,
+	n int,
+	pred func(word string) bool,
+) []Item {
+	return GetMultiWithQuota(con, n, word_scheduler.Unlimited, pred)
+}
+
+// Like GetMulti, but doesn't schedule more new words and reviews than the
+// quota allows.
+func GetMultiWithQuota(
+	con *database.Connection,
+	n int,
+	quota word_scheduler.Quota,
+	pred func(word string) bool,
+) []Item {
+	words, err := word_scheduler.GetWordsWithQuota(con, n, quota, pred)
+	if err != nil {
+		return nil
+	}
+	return generateMultiItems(con, words)
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Card kinds other than cloze.
+package flashcards
+
+import (
+	"database/sql"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/word_scheduler"
+)
+
+// Number of wrong choices in multiple choice cards.
+const numDistractors = 3
+
+// Returns the first blank in the item.
+func firstAnswer(item Item) (Answer, bool) {
+	for _, part := range item.Sentence.Parts {
+		if len(part.Answers) > 0 {
+			return part.Answers[0], true
+		}
+	}
+	return Answer{}, false
+}
+
+// Capitalizes the first letter of s.
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	if r == utf8.RuneError {
+		return s
+	}
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+// Gets words with frequency class close to the word's.
+// Falls back to random words if there aren't enough similar words.
+func getDistractors[T database.Querier](q T, word string, n int) ([]string, error) {
+	query := `
+		SELECT word FROM word
+		WHERE word != @word AND abs(frequency_class - coalesce(
+			(SELECT frequency_class FROM word WHERE word = @word),
+			0
+		)) <= @distance
+		ORDER BY random() LIMIT @n
+	`
+
+	// Widen the search if there aren't enough words of similar frequency.
+	var distractors []string
+	for _, distance := range []int{1, 1 << 16} {
+		rows, err := q.Query(
+			query,
+			sql.Named("word", word),
+			sql.Named("distance", distance),
+			sql.Named("n", n),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get distractors: %w", err)
+		}
+
+		distractors = nil
+		for rows.Next() {
+			var distractor string
+			if err := rows.Scan(&distractor); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to get distractors: %w", err)
+			}
+			distractors = append(distractors, distractor)
+		}
+		rows.Close()
+
+		if len(distractors) >= n {
+			break
+		}
+	}
+	return distractors, nil
+}
+
+// Turns cloze item into a multiple choice card.
+func addChoices[T database.Querier](q T, item *Item) error {
+	answer, ok := firstAnswer(*item)
+	if !ok {
+		return fmt.Errorf("failed to add choices: item has no blanks")
+	}
+
+	distractors, err := getDistractors(q, answer.Normalized, numDistractors)
+	if err != nil {
+		return err
+	}
+
+	// Match the case of the answer, so that the answer doesn't stand out.
+	upper := answer.Text != strings.ToLower(answer.Text)
+	choices := []string{answer.Text}
+	for _, distractor := range distractors {
+		if upper {
+			distractor = capitalize(distractor)
+		}
+		choices = append(choices, distractor)
+	}
+	rand.Shuffle(len(choices), func(i, j int) {
+		choices[i], choices[j] = choices[j], choices[i]
+	})
+
+	item.Kind = rs.Choice
+	item.Choices = choices
+	return nil
+}
+
+// Turns cloze item into a word-order scramble.
+// Whitespace isn't included in the scrambled tokens.
+func addScramble(item *Item) error {
+	var tokens []string
+	for _, part := range item.Sentence.Parts {
+		if strings.TrimSpace(part.Text) != "" {
+			tokens = append(tokens, part.Text)
+		}
+	}
+	if len(tokens) < 2 {
+		return fmt.Errorf("failed to scramble sentence: too few tokens")
+	}
+
+	scrambled := make([]string, len(tokens))
+	copy(scrambled, tokens)
+
+	// Reshuffle a few times if the order didn't change.
+	for i := 0; i < 3 && strings.Join(scrambled, " ") == strings.Join(tokens, " "); i++ {
+		rand.Shuffle(len(scrambled), func(i, j int) {
+			scrambled[i], scrambled[j] = scrambled[j], scrambled[i]
+		})
+	}
+
+	item.Kind = rs.Scramble
+	item.Scrambled = scrambled
+	return nil
+}
+
+// Converts cloze item into the given kind.
+func convertItem[T database.Querier](q T, kind rs.Kind, item *Item) error {
+	switch kind {
+	case rs.Production:
+		// The client shows the translation and checks the whole sentence.
+		item.Kind = rs.Production
+		return nil
+	case rs.Choice:
+		return addChoices(q, item)
+	case rs.Scramble:
+		return addScramble(item)
+	default:
+		return nil
+	}
+}
+
+// Returns flashcards of the given kind, scheduled in the kind's review track.
+// n: max number of flashcards to return.
+// Database connection should have access to course and review data.
+func GetKind(
+	con *database.Connection,
+	kind rs.Kind,
+	n int,
+	pred func(word string) bool,
+) []Item {
+	if kind.IsCloze() {
+		return Get(con, n, pred)
+	}
+
+	words, err := word_scheduler.GetCardWordsWith(con, kind, n, pred)
+	if err != nil {
+		return nil
+	}
+
+	// To make sure JSON encoding is not nil:
+	items := make([]Item, 0)
+	for _, item := range generateItems(con, words) {
+		if err := convertItem(con, kind, &item); err == nil {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package flashcards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+)
+
+func TestGetKindOnlyIncludesLearnedWords(t *testing.T) {
+	// Words have to be learned in the cloze track first.
+	t.Parallel()
+	db := smallCourse("eins", "zwei", "drei", "vier", "fünf")
+	defer db.Close()
+
+	if err := rs.UpdateReview(db, "eins", true); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	con, err := database.NewConnection(db, context.Background())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	items := GetKind(con, rs.Choice, 10, pred)
+	if len(items) != 1 {
+		t.Fatal("expected one flashcard:", items)
+	}
+
+	item := items[0]
+	if item.Kind != rs.Choice {
+		t.Fatal("expected multiple choice flashcard:", item.Kind)
+	}
+	if len(item.Choices) != numDistractors+1 {
+		t.Fatal("expected answer and distractors:", item.Choices)
+	}
+
+	found := false
+	for _, choice := range item.Choices {
+		if choice == "eins" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatal("expected choices to contain answer:", item.Choices)
+	}
+}
+
+func TestAddScramble(t *testing.T) {
+	t.Parallel()
+
+	item := Item{
+		Sentence: Sentence{
+			Parts: []Part{
+				{Text: "Ich"},
+				{Text: " "},
+				{Text: "bin"},
+				{Text: " "},
+				{Text: "hier"},
+				{Text: "."},
+			},
+		},
+	}
+	if err := addScramble(&item); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if item.Kind != rs.Scramble || len(item.Scrambled) != 4 {
+		t.Fatal("expected scrambled non-whitespace tokens:", item.Scrambled)
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Flashcards for lemmas.
+package flashcards
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/sentences"
+	"github.com/polycloze/polycloze/text"
+	"github.com/polycloze/polycloze/translator"
+	"github.com/polycloze/polycloze/word_scheduler"
+)
+
+// Returns parts of cloze item that blanks an inflected form of the lemma.
+// The answer's normalized form is the review item (usually the lemma), so that
+// the review gets saved under the same item.
+func getLemmaParts(tokens []string, forms []string, lemma word_scheduler.Word) ([]Part, error) {
+	var indices []int
+	for _, form := range forms {
+		indices = append(indices, matchingTokens(tokens, text.Casefold(form))...)
+	}
+	if len(indices) == 0 {
+		return nil, fmt.Errorf("no form of lemma in sentence: %v, %v", lemma.Word, tokens)
+	}
+
+	index := indices[rand.Intn(len(indices))]
+	return splitParts(tokens, map[int]word_scheduler.Word{index: lemma}), nil
+}
+
+func generateLemmaItem[T database.Querier](q T, lemma word_scheduler.Word) (Item, error) {
+	var item Item
+
+	sentence, forms, err := sentences.PickLemmaSentence(q, lemma.Word)
+	if err != nil {
+		return item, err
+	}
+
+	parts, err := getLemmaParts(sentence.Tokens, forms, lemma)
+	if err != nil {
+		return item, err
+	}
+
+	translation, err := translator.Translate(q, sentence)
+	if err != nil {
+		// Panic because this shouldn't happen with generated course files.
+		panic(fmt.Errorf("could not translate sentence (%v): %w", sentence, err))
+	}
+	return Item{
+		Translation: translation,
+		Sentence: Sentence{
+			ID:        sentence.ID,
+			Parts:     parts,
+			TatoebaID: sentence.TatoebaID,
+			Custom:    sentence.Custom,
+		},
+	}, nil
+}
+
+// Like Get, but schedules lemmas instead of words.
+// Falls back to Get if the course DB doesn't have lemmas.
+func GetLemmas(
+	con *database.Connection,
+	n int,
+	pred func(word string) bool,
+) []Item {
+	return GetLemmasWithQuota(con, n, word_scheduler.Unlimited, pred)
+}
+
+// Like GetLemmas, but doesn't return more new words and reviews than the quota
+// allows.
+func GetLemmasWithQuota(
+	con *database.Connection,
+	n int,
+	quota word_scheduler.Quota,
+	pred func(word string) bool,
+) []Item {
+	if !sentences.HasLemmas(con) {
+		return GetWithQuota(con, n, quota, pred)
+	}
+
+	words, err := word_scheduler.GetLemmasWithQuota(con, n, quota, pred)
+	if err != nil {
+		return nil
+	}
+	return generateItemsWith(con, words, generateLemmaItem[*database.Connection])
+}
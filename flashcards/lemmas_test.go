@@ -0,0 +1,100 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package flashcards
+
+import (
+	"context"
+	"testing"
+
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+)
+
+func TestGetLemmas(t *testing.T) {
+	// Inflected forms should be scheduled as one item.
+	t.Parallel()
+	db := smallCourse("gehen", "ging", "gegangen")
+	defer db.Close()
+
+	queries := []string{
+		`CREATE TABLE lemma (word INTEGER PRIMARY KEY, lemma TEXT NOT NULL)`,
+		`INSERT INTO lemma (word, lemma) VALUES (2, 'gehen'), (3, 'gehen')`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	con, err := database.NewConnection(db, context.Background())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	items := GetLemmas(con, 10, pred)
+	if len(items) != 1 {
+		t.Fatal("expected one flashcard for the lemma:", items)
+	}
+
+	words := items[0].Words()
+	if len(words) != 1 || words[0] != "gehen" {
+		t.Fatal("expected review to be saved under the lemma:", words)
+	}
+}
+
+func TestGetLemmasWithoutLemmaTable(t *testing.T) {
+	// Should fall back to surface forms.
+	t.Parallel()
+	db := smallCourse("gehen", "ging")
+	defer db.Close()
+
+	con, err := database.NewConnection(db, context.Background())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	if items := GetLemmas(con, 10, pred); len(items) != 2 {
+		t.Fatal("expected one flashcard per word:", items)
+	}
+}
+
+func TestGetLemmasAfterSwitchingModes(t *testing.T) {
+	// Inflected forms that were reviewed before lemma mode was turned on should
+	// still get flashcards, and their lemmas shouldn't come back as new words.
+	t.Parallel()
+	db := smallCourse("gehen", "ging", "gegangen")
+	defer db.Close()
+
+	if err := rs.UpdateReview(db, "ging", false); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	queries := []string{
+		`CREATE TABLE lemma (word INTEGER PRIMARY KEY, lemma TEXT NOT NULL)`,
+		`INSERT INTO lemma (word, lemma) VALUES (2, 'gehen'), (3, 'gehen')`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	con, err := database.NewConnection(db, context.Background())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	items := GetLemmas(con, 10, pred)
+	if len(items) != 1 {
+		t.Fatal("expected one flashcard for the reviewed form:", items)
+	}
+
+	words := items[0].Words()
+	if len(words) != 1 || words[0] != "ging" {
+		t.Fatal("expected review to be saved under the reviewed form:", words)
+	}
+}
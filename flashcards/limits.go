@@ -0,0 +1,33 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package flashcards
+
+import (
+	"github.com/polycloze/polycloze/word_scheduler"
+)
+
+// Returns items that fit in the quota, and uses up the quota for them.
+// Used to filter prefetched flashcards, which were generated before the quota
+// was known.
+func ApplyQuota(items []Item, quota *word_scheduler.Quota) []Item {
+	// To make sure JSON encoding is not nil:
+	result := make([]Item, 0)
+	for _, item := range items {
+		remaining := *quota
+		fits := true
+		for _, part := range item.Sentence.Parts {
+			for _, answer := range part.Answers {
+				word := word_scheduler.Word{Word: answer.Normalized, New: answer.New}
+				if !remaining.Take(word) {
+					fits = false
+				}
+			}
+		}
+		if fits {
+			*quota = remaining
+			result = append(result, item)
+		}
+	}
+	return result
+}
@@ -24,6 +24,17 @@ type Answer struct {
 
 	// Only has to be meaningful for new words.
 	Difficulty int `json:"difficulty"`
+
+	// Other accepted answers.
+	Alternatives []Alternative `json:"alternatives,omitempty"`
+}
+
+// Alternative answer (e.g. alternate spelling, answer without diacritics).
+type Alternative struct {
+	Text string `json:"text"`
+
+	// Counts as fully correct if true, as a near miss otherwise.
+	Correct bool `json:"correct"`
 }
 
 // Parts of a sentence.
@@ -33,19 +44,24 @@ type Part struct {
 	Answers []Answer `json:"answers,omitempty"`
 }
 
-// Returns parts of cloze item.
-func getParts(tokens []string, word word_scheduler.Word) []Part {
-	// TODO word: string -> Word
-	normalized := text.Casefold(word.Word)
-
-	// Find all matching tokens.
+// Returns indices of tokens that match the word.
+func matchingTokens(tokens []string, normalized string) []int {
 	var indices []int
 	for i, token := range tokens {
 		if text.Casefold(token) == normalized {
 			indices = append(indices, i)
 		}
 	}
+	return indices
+}
+
+// Returns parts of cloze item.
+func getParts(tokens []string, word word_scheduler.Word) []Part {
+	// TODO word: string -> Word
+	normalized := text.Casefold(word.Word)
 
+	// Find all matching tokens.
+	indices := matchingTokens(tokens, normalized)
 	if len(indices) == 0 {
 		message := fmt.Sprintf(
 			"Python casefold different from golang casefold: %s, %v",
@@ -58,24 +74,65 @@ func getParts(tokens []string, word word_scheduler.Word) []Part {
 	// Pick a random one if there are multiple matches.
 	// TODO turn all matching tokens into blanks instead.
 	index := indices[rand.Intn(len(indices))]
+	return splitParts(tokens, map[int]word_scheduler.Word{index: word})
+}
 
-	before := Part{
-		Text: strings.Join(tokens[:index], ""),
+// Returns parts of cloze item with a blank for each word.
+// Words that don't appear in the sentence are skipped.
+// Also returns the words that got blanked, in the order they appear in the
+// sentence.
+func getMultiParts(
+	tokens []string,
+	words []word_scheduler.Word,
+) ([]Part, []word_scheduler.Word) {
+	blanks := make(map[int]word_scheduler.Word)
+	for _, word := range words {
+		// Pick a random unused token if there are multiple matches.
+		var indices []int
+		for _, i := range matchingTokens(tokens, text.Casefold(word.Word)) {
+			if _, used := blanks[i]; !used {
+				indices = append(indices, i)
+			}
+		}
+		if len(indices) > 0 {
+			blanks[indices[rand.Intn(len(indices))]] = word
+		}
 	}
-	after := Part{
-		Text: strings.Join(tokens[index+1:], ""),
+
+	var blanked []word_scheduler.Word
+	for i := range tokens {
+		if word, ok := blanks[i]; ok {
+			blanked = append(blanked, word)
+		}
 	}
+	return splitParts(tokens, blanks), blanked
+}
 
-	missing := Part{
-		Text: tokens[index],
-		Answers: []Answer{
-			{
-				Text:       tokens[index],
-				Normalized: normalized,
-				New:        word.New,
-				Difficulty: word.Difficulty,
+// Splits tokens into parts.
+// blanks maps token indices to words that should be blanked.
+// Non-blank parts are always put between blanks, even if they're empty, so
+// that odd-numbered parts are blanks.
+func splitParts(tokens []string, blanks map[int]word_scheduler.Word) []Part {
+	var parts []Part
+	start := 0
+	for i, token := range tokens {
+		word, ok := blanks[i]
+		if !ok {
+			continue
+		}
+		parts = append(parts, Part{Text: strings.Join(tokens[start:i], "")})
+		parts = append(parts, Part{
+			Text: token,
+			Answers: []Answer{
+				{
+					Text:       token,
+					Normalized: text.Casefold(word.Word),
+					New:        word.New,
+					Difficulty: word.Difficulty,
+				},
 			},
-		},
+		})
+		start = i + 1
 	}
-	return []Part{before, missing, after}
+	return append(parts, Part{Text: strings.Join(tokens[start:], "")})
 }
@@ -0,0 +1,68 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package flashcards
+
+import (
+	"testing"
+
+	"github.com/polycloze/polycloze/word_scheduler"
+)
+
+func TestGetMultiParts(t *testing.T) {
+	// Odd-numbered parts should be blanks.
+	t.Parallel()
+
+	tokens := []string{"Der", " ", "Hund", " ", "und", " ", "die", " ", "Katze", "."}
+	words := []word_scheduler.Word{
+		{Word: "katze"},
+		{Word: "hund", New: true},
+		{Word: "maus"},
+	}
+
+	parts, blanked := getMultiParts(tokens, words)
+	if len(parts) != 5 {
+		t.Fatal("expected two blanks:", parts)
+	}
+	if len(blanked) != 2 || blanked[0].Word != "hund" || blanked[1].Word != "katze" {
+		t.Fatal("expected blanked words in sentence order:", blanked)
+	}
+
+	for i, part := range parts {
+		if (i%2 == 1) != (len(part.Answers) > 0) {
+			t.Fatal("expected odd-numbered parts to be blanks:", parts)
+		}
+	}
+	if parts[1].Text != "Hund" || !parts[1].Answers[0].New {
+		t.Fatal("expected first blank to be new word:", parts[1])
+	}
+	if parts[4].Text != "." {
+		t.Fatal("expected sentence to end with non-blank part:", parts[4])
+	}
+}
+
+func TestGetMultiPartsAdjacent(t *testing.T) {
+	// Adjacent blanks should be separated by empty parts.
+	t.Parallel()
+
+	tokens := []string{"ab", "cd"}
+	words := []word_scheduler.Word{{Word: "ab"}, {Word: "cd"}}
+
+	parts, _ := getMultiParts(tokens, words)
+	if len(parts) != 5 || parts[0].Text != "" || parts[2].Text != "" || parts[4].Text != "" {
+		t.Fatal("expected empty parts between blanks:", parts)
+	}
+}
+
+func TestGetMultiPartsRepeatedWord(t *testing.T) {
+	// A word that appears multiple times should only be blanked once.
+	t.Parallel()
+
+	tokens := []string{"so", " ", "so"}
+	words := []word_scheduler.Word{{Word: "so"}}
+
+	parts, blanked := getMultiParts(tokens, words)
+	if len(parts) != 3 || len(blanked) != 1 {
+		t.Fatal("expected one blank:", parts)
+	}
+}
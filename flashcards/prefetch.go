@@ -0,0 +1,263 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Background generation of flashcards.
+package flashcards
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/text"
+)
+
+// Prefetcher drops idle buffers when there are more than this many.
+const maxBuffers = 1024
+
+// Returns DB with access to course and review data, and a function to call
+// after using the DB.
+type Opener func() (*sql.DB, func(), error)
+
+type prefetchedItem struct {
+	item      Item
+	generated time.Time
+}
+
+type prefetchBuffer struct {
+	items []prefetchedItem
+
+	// Incremented on invalidation, so that stale refills get discarded.
+	version int
+
+	running bool
+
+	// Words to exclude in the next refill, if one was requested while another
+	// one was running.
+	pending []string
+	opener  Opener
+}
+
+// Returns words in the buffer.
+func (b *prefetchBuffer) words() []string {
+	var words []string
+	for _, p := range b.items {
+		words = append(words, p.item.Words()...)
+	}
+	return words
+}
+
+// Generates flashcards in the background, so that requests can be answered
+// without waiting for the sentence and translation queries.
+// Buffers are keyed by review DB.
+type Prefetcher struct {
+	size   int
+	maxAge time.Duration
+
+	mu      sync.Mutex
+	buffers map[string]*prefetchBuffer
+	closed  bool
+	wg      sync.WaitGroup
+}
+
+// Creates prefetcher that keeps up to size flashcards per review DB.
+// Flashcards older than maxAge are discarded, because words may have become
+// due for review since they were generated.
+func NewPrefetcher(size int, maxAge time.Duration) *Prefetcher {
+	return &Prefetcher{
+		size:    size,
+		maxAge:  maxAge,
+		buffers: make(map[string]*prefetchBuffer),
+	}
+}
+
+// Returns buffer for the key.
+// The caller must hold the lock.
+func (p *Prefetcher) buffer(key string) *prefetchBuffer {
+	b, ok := p.buffers[key]
+	if !ok {
+		if len(p.buffers) >= maxBuffers {
+			p.prune(time.Now())
+		}
+		b = &prefetchBuffer{}
+		p.buffers[key] = b
+	}
+	return b
+}
+
+// Removes buffers that aren't being refilled and have no fresh flashcards.
+// The caller must hold the lock.
+func (p *Prefetcher) prune(now time.Time) {
+	for key, b := range p.buffers {
+		if b.running {
+			continue
+		}
+		fresh := false
+		for _, item := range b.items {
+			if now.Sub(item.generated) < p.maxAge {
+				fresh = true
+				break
+			}
+		}
+		if !fresh {
+			delete(p.buffers, key)
+		}
+	}
+}
+
+// Takes up to n prefetched flashcards.
+// Flashcards with words that don't satisfy the predicate are dropped, because
+// the client already has them.
+func (p *Prefetcher) Take(key string, n int, pred func(word string) bool) []Item {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// To make sure JSON encoding is not nil:
+	items := make([]Item, 0)
+
+	b, ok := p.buffers[key]
+	if !ok {
+		return items
+	}
+
+	now := time.Now()
+	var remaining []prefetchedItem
+	for _, prefetched := range b.items {
+		if now.Sub(prefetched.generated) >= p.maxAge {
+			continue
+		}
+		if !allSatisfy(prefetched.item.Words(), pred) {
+			continue
+		}
+		if len(items) < n {
+			items = append(items, prefetched.item)
+		} else {
+			remaining = append(remaining, prefetched)
+		}
+	}
+	b.items = remaining
+	return items
+}
+
+// Discards prefetched flashcards.
+// Should be called when the user's review schedule changes.
+func (p *Prefetcher) Invalidate(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if b, ok := p.buffers[key]; ok {
+		b.items = nil
+		b.pending = nil
+		b.version++
+	}
+}
+
+// Generates flashcards in the background until the buffer is full.
+// exclude: words that the client already has
+func (p *Prefetcher) Refill(key string, exclude []string, opener Opener) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.closed {
+		return
+	}
+
+	b := p.buffer(key)
+	if b.running {
+		b.pending = exclude
+		b.opener = opener
+		return
+	}
+
+	b.running = true
+	p.wg.Add(1)
+	go p.fill(b, exclude, opener)
+}
+
+// Fills buffer, then runs pending refills.
+func (p *Prefetcher) fill(b *prefetchBuffer, exclude []string, opener Opener) {
+	defer p.wg.Done()
+
+	for {
+		p.mu.Lock()
+		version := b.version
+		n := p.size - len(b.items)
+		exclude = append(exclude, b.words()...)
+		p.mu.Unlock()
+
+		var items []Item
+		if n > 0 {
+			var err error
+			items, err = generate(opener, n, exclude)
+			if err != nil {
+				log.Println(err)
+			}
+		}
+
+		p.mu.Lock()
+		if b.version == version {
+			now := time.Now()
+			for _, item := range items {
+				b.items = append(b.items, prefetchedItem{item: item, generated: now})
+			}
+		}
+
+		if b.pending == nil || p.closed {
+			b.running = false
+			b.pending = nil
+			b.opener = nil
+			p.mu.Unlock()
+			return
+		}
+		exclude, opener = b.pending, b.opener
+		b.pending = nil
+		b.opener = nil
+		p.mu.Unlock()
+	}
+}
+
+// Generates up to n flashcards that don't contain excluded words.
+func generate(opener Opener, n int, exclude []string) ([]Item, error) {
+	db, release, err := opener()
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	con, err := database.NewConnection(db, context.Background())
+	if err != nil {
+		return nil, err
+	}
+	defer con.Close()
+
+	excluded := make(map[string]bool)
+	for _, word := range exclude {
+		excluded[text.Casefold(word)] = true
+	}
+	return Get(con, n, func(word string) bool {
+		return !excluded[text.Casefold(word)]
+	}), nil
+}
+
+// Waits for background refills to finish and stops new ones from starting.
+func (p *Prefetcher) Close() error {
+	p.mu.Lock()
+	p.closed = true
+	p.mu.Unlock()
+
+	p.wg.Wait()
+	return nil
+}
+
+// Checks if all words satisfy the predicate.
+func allSatisfy(words []string, pred func(word string) bool) bool {
+	for _, word := range words {
+		if !pred(word) {
+			return false
+		}
+	}
+	return true
+}
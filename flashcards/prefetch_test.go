@@ -0,0 +1,138 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package flashcards
+
+import (
+	"database/sql"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/utils"
+)
+
+// Returns course with one sentence for each word.
+// NOTE Caller should close DB.
+func smallCourse(words ...string) *sql.DB {
+	db := utils.TestingDatabase()
+	db.SetMaxOpenConns(1)
+
+	queries := []string{
+		`INSERT INTO language (id, code, name, bcp47) VALUES
+			('l1', 'eng', 'English', 'en'), ('l2', 'deu', 'Deutsch', 'de')`,
+	}
+	for i, word := range words {
+		queries = append(
+			queries,
+			fmt.Sprintf(`INSERT INTO word (id, word, frequency_class) VALUES (%d, '%s', 0)`, i+1, word),
+			fmt.Sprintf(
+				`INSERT INTO sentence (id, tatoeba_id, text, tokens, frequency_class)
+				VALUES (%d, %d, '%s.', '["%s", "."]', 0)`,
+				i+1, i+1, word, word,
+			),
+			fmt.Sprintf(`INSERT INTO contains (sentence, word) VALUES (%d, %d)`, i+1, i+1),
+			fmt.Sprintf(`INSERT INTO translation (id, tatoeba_id, text) VALUES (%d, %d, '%s?')`, i+1, 100+i, word),
+			fmt.Sprintf(`INSERT INTO translates (source, target) VALUES (%d, %d)`, i+1, 100+i),
+		)
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			panic(err)
+		}
+	}
+	return db
+}
+
+func opener(db *sql.DB) Opener {
+	return func() (*sql.DB, func(), error) {
+		return db, func() {}, nil
+	}
+}
+
+func TestPrefetcherRefillAndTake(t *testing.T) {
+	// Prefetched flashcards shouldn't contain excluded words.
+	t.Parallel()
+	db := smallCourse("eins", "zwei", "drei")
+	defer db.Close()
+
+	p := NewPrefetcher(10, time.Hour)
+	defer p.Close()
+
+	p.Refill("key", []string{"zwei"}, opener(db))
+	p.wg.Wait()
+
+	items := p.Take("key", 10, pred)
+	if len(items) != 2 {
+		t.Fatal("expected two flashcards:", items)
+	}
+	for _, item := range items {
+		for _, word := range item.Words() {
+			if word == "zwei" {
+				t.Fatal("expected excluded word to not be prefetched:", items)
+			}
+		}
+	}
+
+	if items := p.Take("key", 10, pred); len(items) != 0 {
+		t.Fatal("expected flashcards to be taken only once:", items)
+	}
+}
+
+func TestPrefetcherTakeDropsClientWords(t *testing.T) {
+	// Flashcards the client already has should be dropped.
+	t.Parallel()
+	db := smallCourse("eins", "zwei", "drei")
+	defer db.Close()
+
+	p := NewPrefetcher(10, time.Hour)
+	defer p.Close()
+
+	p.Refill("key", nil, opener(db))
+	p.wg.Wait()
+
+	items := p.Take("key", 1, func(word string) bool {
+		return word != "eins"
+	})
+	if len(items) != 1 || items[0].Words()[0] == "eins" {
+		t.Fatal("expected one flashcard without excluded word:", items)
+	}
+
+	items = p.Take("key", 10, pred)
+	if len(items) != 1 || items[0].Words()[0] == "eins" {
+		t.Fatal("expected remaining flashcard without excluded word:", items)
+	}
+}
+
+func TestPrefetcherInvalidate(t *testing.T) {
+	t.Parallel()
+	db := smallCourse("eins", "zwei")
+	defer db.Close()
+
+	p := NewPrefetcher(10, time.Hour)
+	defer p.Close()
+
+	p.Refill("key", nil, opener(db))
+	p.wg.Wait()
+	p.Invalidate("key")
+
+	if items := p.Take("key", 10, pred); len(items) != 0 {
+		t.Fatal("expected invalidated flashcards to be discarded:", items)
+	}
+}
+
+func TestPrefetcherMaxAge(t *testing.T) {
+	t.Parallel()
+	db := smallCourse("eins", "zwei")
+	defer db.Close()
+
+	p := NewPrefetcher(10, 0)
+	defer p.Close()
+
+	p.Refill("key", nil, opener(db))
+	p.wg.Wait()
+
+	if items := p.Take("key", 10, pred); len(items) != 0 {
+		t.Fatal("expected stale flashcards to be discarded:", items)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"database/sql"
 	"fmt"
 	"time"
+
+	"github.com/polycloze/polycloze/review_scheduler"
 )
 
 // Represents a record in the review history.
@@ -15,6 +17,7 @@ type Review struct {
 	Reviewed       time.Time
 	IntervalBefore time.Duration // Negative if null
 	IntervalAfter  time.Duration
+	Grade          review_scheduler.Grade // Zero if unknown
 }
 
 // Summary of reviews within an interval of time.
@@ -91,7 +94,8 @@ func Summarize(db *sql.DB, from, to time.Time, step time.Duration) ([]Summary, e
 // Specify a negative `limit` to return an unbounded number of `Review`s.
 func Get(db *sql.DB, from, to time.Time, step time.Duration, limit int) ([]Review, error) {
 	query := `
-		SELECT word, reviewed, coalesce(interval_before, -1), interval_after
+		SELECT word, reviewed, coalesce(interval_before, -1), interval_after,
+			coalesce(grade, 0)
 		FROM history
 		WHERE reviewed >= ? AND reviewed < ?
 		ORDER BY reviewed DESC
@@ -114,7 +118,13 @@ func Get(db *sql.DB, from, to time.Time, step time.Duration, limit int) ([]Revie
 		var intervalBefore, intervalAfter time.Duration
 		var review Review
 
-		err = rows.Scan(&review.Word, &reviewed, &intervalBefore, &intervalAfter)
+		err = rows.Scan(
+			&review.Word,
+			&reviewed,
+			&intervalBefore,
+			&intervalAfter,
+			&review.Grade,
+		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to get past reviews: %w", err)
 		}
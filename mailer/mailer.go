@@ -0,0 +1,114 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Email delivery (e.g. password reset links).
+package mailer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var errInvalidHeader = errors.New("invalid header")
+
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Delivers messages.
+type Mailer interface {
+	Send(m Message) error
+}
+
+// Formats message as RFC 5322 text.
+// Rejects headers that could inject other headers.
+func format(from string, m Message) ([]byte, error) {
+	to, err := mail.ParseAddress(m.To)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recipient: %w", err)
+	}
+	if strings.ContainsAny(from+m.Subject, "\r\n") {
+		return nil, errInvalidHeader
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %v\r\n", from)
+	fmt.Fprintf(&b, "To: %v\r\n", to.String())
+	fmt.Fprintf(&b, "Subject: %v\r\n", m.Subject)
+	fmt.Fprintf(&b, "Date: %v\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(m.Body, "\n", "\r\n"))
+	return b.Bytes(), nil
+}
+
+// Writes messages to files in a directory instead of sending them.
+// Useful for testing and for servers without outgoing mail.
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+func (f FileMailer) Send(m Message) error {
+	data, err := format(f.From, m)
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	if err := os.MkdirAll(f.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	name := fmt.Sprintf("%v-%v.eml", time.Now().UTC().Format("20060102T150405Z"), hex.EncodeToString(suffix))
+	if err := os.WriteFile(filepath.Join(f.Dir, name), data, 0o600); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
+
+// Sends messages through an SMTP server.
+type SMTPMailer struct {
+	Addr     string // host:port
+	Username string // Doesn't authenticate if empty
+	Password string
+	From     string
+}
+
+func (s SMTPMailer) Send(m Message) error {
+	data, err := format(s.From, m)
+	if err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("failed to send mail: invalid sender: %w", err)
+	}
+	to, _ := mail.ParseAddress(m.To)
+
+	var auth smtp.Auth
+	if s.Username != "" {
+		host, _, _ := net.SplitHostPort(s.Addr)
+		auth = smtp.PlainAuth("", s.Username, s.Password, host)
+	}
+	if err := smtp.SendMail(s.Addr, auth, from.Address, []string{to.Address}, data); err != nil {
+		return fmt.Errorf("failed to send mail: %w", err)
+	}
+	return nil
+}
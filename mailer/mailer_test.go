@@ -0,0 +1,56 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package mailer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileMailer(t *testing.T) {
+	t.Parallel()
+
+	dir := filepath.Join(t.TempDir(), "mail")
+	m := FileMailer{Dir: dir, From: "polycloze <noreply@example.com>"}
+	message := Message{
+		To:      "foo@example.com",
+		Subject: "Reset your password",
+		Body:    "https://example.com/reset?token=abc\n",
+	}
+	if err := m.Send(message); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	matches, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(matches) != 1 {
+		t.Fatal("expected one message file:", matches, err)
+	}
+	data, err := os.ReadFile(matches[0])
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	text := string(data)
+	for _, expected := range []string{"To: <foo@example.com>", "Subject: Reset your password", "token=abc"} {
+		if !strings.Contains(text, expected) {
+			t.Fatalf("expected message to contain %q:\n%v", expected, text)
+		}
+	}
+}
+
+func TestFormatRejectsHeaderInjection(t *testing.T) {
+	t.Parallel()
+
+	invalid := []Message{
+		{To: "foo@example.com", Subject: "Hi\r\nBcc: bar@example.com"},
+		{To: "foo@example.com\r\nBcc: bar@example.com", Subject: "Hi"},
+		{To: "not an address", Subject: "Hi"},
+	}
+	for _, m := range invalid {
+		if _, err := format("noreply@example.com", m); err == nil {
+			t.Fatalf("expected message to be rejected: %#v", m)
+		}
+	}
+}
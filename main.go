@@ -4,48 +4,189 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 	"log"
+	"net"
 	"net/http"
 	"os"
-	"strconv"
+	"os/signal"
+	"path"
+	"syscall"
+	"time"
 
 	"github.com/polycloze/polycloze/api"
+	"github.com/polycloze/polycloze/auth"
 	"github.com/polycloze/polycloze/basedir"
+	"github.com/polycloze/polycloze/config"
 	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/flashcards"
+	"github.com/polycloze/polycloze/mailer"
+	"github.com/polycloze/polycloze/sessions"
+	"github.com/polycloze/polycloze/tts"
 )
 
 type Args struct {
+	config string
+
 	cors bool
 	port int
+
+	maxOpenDBs  int
+	idleDB      time.Duration
+	prefetch    int
+	prefetchAge time.Duration
+
+	tts    string
+	ttsExt string
 }
 
-func defaultPortNumber() int {
-	port := os.Getenv("PORT")
-	if port != "" {
-		v, err := strconv.Atoi(port)
-		if err == nil {
-			return v
-		}
+// Returns path to config file.
+// Looks in $POLYCLOZE_CONFIG, then in $XDG_CONFIG_HOME/polycloze.
+func defaultConfigFile() string {
+	if file := os.Getenv("POLYCLOZE_CONFIG"); file != "" {
+		return file
 	}
-	return 3000
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return path.Join(dir, "polycloze", "config.json")
 }
 
 func parseArgs() Args {
 	var args Args
 
-	flag.BoolVar(&args.cors, "c", false, "allow CORS")
-	flag.IntVar(&args.port, "p", defaultPortNumber(), "port number")
+	defaults := config.Default()
+	flag.StringVar(&args.config, "config", defaultConfigFile(), "path to JSON config file")
+	flag.BoolVar(&args.cors, "c", false, "allow CORS from every origin")
+	flag.IntVar(&args.port, "p", 3000, "port number (overrides $PORT)")
+	flag.IntVar(&args.maxOpenDBs, "max-open-dbs", defaults.MaxOpenDBs, "max number of review DBs to keep open")
+	flag.IntVar(&args.prefetch, "prefetch", defaults.Prefetch, "number of flashcards to generate in the background per user")
+	flag.DurationVar(&args.prefetchAge, "prefetch-max-age", time.Duration(defaults.PrefetchMaxAge), "discard prefetched flashcards older than this")
+	flag.DurationVar(&args.idleDB, "idle-db-timeout", time.Duration(defaults.IdleDBTimeout), "close review DBs that haven't been used for this long")
+	flag.StringVar(&args.tts, "tts", "", "local TTS command that reads text from stdin (placeholders: {lang}, {output})")
+	flag.StringVar(&args.ttsExt, "tts-ext", defaults.TTS.Ext, "extension of audio files created by the TTS command")
 	flag.Parse()
 	return args
 }
 
+// Loads config file, then applies overrides from the environment and from
+// flags that were set explicitly.
+// A missing config file is only an error if the -config flag is set.
+func loadConfig(args Args) (config.Config, error) {
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	c, err := config.Load(args.config)
+	if errors.Is(err, fs.ErrNotExist) && !explicit["config"] {
+		c, err = config.Default(), nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	if port := os.Getenv("PORT"); port != "" {
+		c.Listen = ":" + port
+	}
+
+	overrides := map[string]func(){
+		"c":                func() { c.CORS.Origins = []string{"*"} },
+		"p":                func() { c.Listen = fmt.Sprintf(":%v", args.port) },
+		"max-open-dbs":     func() { c.MaxOpenDBs = args.maxOpenDBs },
+		"prefetch":         func() { c.Prefetch = args.prefetch },
+		"prefetch-max-age": func() { c.PrefetchMaxAge = config.Duration(args.prefetchAge) },
+		"idle-db-timeout":  func() { c.IdleDBTimeout = config.Duration(args.idleDB) },
+		"tts":              func() { c.TTS.Command = args.tts },
+		"tts-ext":          func() { c.TTS.Ext = args.ttsExt },
+	}
+	for name, override := range overrides {
+		if explicit[name] {
+			override()
+		}
+	}
+	return c, c.Validate()
+}
+
 func main() {
+	args := parseArgs()
+	c, err := loadConfig(args)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if c.Log.File != "" {
+		file, err := os.OpenFile(c.Log.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer file.Close()
+		log.SetOutput(file)
+	}
+
+	if err := basedir.Set(c.DataDir, c.StateDir); err != nil {
+		log.Fatal(err)
+	}
 	api.Startup()
 
-	args := parseArgs()
-	config := api.Config{AllowCORS: args.cors, Port: args.port}
+	registry := database.NewRegistry(c.MaxOpenDBs, time.Duration(c.IdleDBTimeout))
+	defer registry.Close()
+
+	prefetcher := flashcards.NewPrefetcher(c.Prefetch, time.Duration(c.PrefetchMaxAge))
+	defer prefetcher.Close()
+
+	serverConfig := api.Config{
+		CORSOrigins:    c.CORS.Origins,
+		MaxUploadSize:  c.MaxUploadSize,
+		MaxSentences:   c.MaxSentences,
+		RateLimit:      c.RateLimit.RequestsPerMinute,
+		RateLimitBurst: c.RateLimit.Burst,
+		Registration:   c.Registration,
+		TrustProxy:     c.TrustProxy,
+		ClientIPHeader: c.ClientIPHeader,
+		PublicURL:      c.PublicURL,
+		AdminToken:     c.AdminToken,
+		PasswordPolicy: auth.PasswordPolicy{MinLength: c.Password.MinLength},
+		Registry:       registry,
+		Prefetcher:     prefetcher,
+	}
+	if c.Log.Requests {
+		serverConfig.RequestLog = os.Stdout
+		if c.Log.File != "" {
+			serverConfig.RequestLog = log.Writer()
+		}
+	}
+	if c.Password.BreachedList != "" {
+		breached, err := auth.LoadBreachedPasswords(c.Password.BreachedList)
+		if err != nil {
+			log.Fatal(err)
+		}
+		serverConfig.PasswordPolicy.Breached = breached
+	}
+	if c.Mail.Dir != "" {
+		serverConfig.Mailer = mailer.FileMailer{Dir: c.Mail.Dir, From: c.Mail.From}
+	} else if c.Mail.SMTP.Addr != "" {
+		serverConfig.Mailer = mailer.SMTPMailer{
+			Addr:     c.Mail.SMTP.Addr,
+			Username: c.Mail.SMTP.Username,
+			Password: c.Mail.SMTP.Password,
+			From:     c.Mail.From,
+		}
+	}
+	if c.TTS.Command != "" {
+		command, err := tts.ParseCommand(c.TTS.Command, c.TTS.Ext, basedir.TTSCache())
+		if err != nil {
+			log.Fatal(err)
+		}
+		command.MaxConcurrent = c.TTS.MaxConcurrent
+		command.MaxCacheSize = c.TTS.MaxCacheSize
+		serverConfig.TTS = command
+	}
 
 	db, err := database.OpenAuthDB(basedir.Auth())
 	if err != nil {
@@ -53,11 +194,52 @@ func main() {
 	}
 	defer db.Close()
 
-	r, err := api.Router(config, db)
+	stopJanitor := sessions.StartJanitor(db, time.Hour)
+	defer stopJanitor()
+
+	r, err := api.Router(serverConfig, db)
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Printf("Listening on port %v\n", args.port)
-	log.Printf("Start learning: http://127.0.0.1:%v\n", args.port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%v", args.port), r))
+	server := &http.Server{
+		Addr:              c.Listen,
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       time.Duration(c.ReadTimeout),
+		WriteTimeout:      time.Duration(c.WriteTimeout),
+		IdleTimeout:       2 * time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Listening on %v\n", c.Listen)
+		if c.TLS.CertFile != "" {
+			errs <- server.ListenAndServeTLS(c.TLS.CertFile, c.TLS.KeyFile)
+			return
+		}
+		if _, port, err := net.SplitHostPort(c.Listen); err == nil {
+			log.Printf("Start learning: http://127.0.0.1:%v\n", port)
+		}
+		errs <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errs:
+		log.Fatal(err)
+	case <-ctx.Done():
+	}
+
+	// Stop accepting requests, and wait for in-flight requests (e.g. review
+	// uploads) to finish before closing open DBs.
+	// Deferred calls close the DBs.
+	log.Println("Shutting down")
+	stop()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(c.ShutdownTimeout))
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		log.Println("failed to shut down gracefully:", err)
+	}
 }
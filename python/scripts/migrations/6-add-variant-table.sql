@@ -0,0 +1,12 @@
+begin transaction;
+	pragma user_version = 6;
+
+	-- Optional alternative answers (inflections, alternate spellings, etc.).
+	create table if not exists variant (
+		word integer not null references word,
+		text text not null,
+		correct integer not null default 1 check (correct in (0, 1)),	-- 0 if near miss
+		unique (word, text)
+		);
+
+	commit;
@@ -0,0 +1,10 @@
+begin transaction;
+	pragma user_version = 7;
+
+	-- Optional recorded audio of sentences.
+	create table if not exists audio (
+		sentence integer primary key references sentence,
+		path text not null	-- relative to the data directory (served through /share)
+		);
+
+	commit;
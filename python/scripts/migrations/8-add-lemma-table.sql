@@ -0,0 +1,13 @@
+begin transaction;
+	pragma user_version = 8;
+
+	-- Optional mapping from inflected forms to lemmas (e.g. "ran" -> "run").
+	-- Words that aren't in the table are their own lemma.
+	create table if not exists lemma (
+		word integer primary key references word,
+		lemma text not null	-- casefolded, doesn't have to be in the word table
+		);
+
+	create index if not exists index_lemma_lemma on lemma (lemma);
+
+	commit;
@@ -0,0 +1,212 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Review history export.
+package replay
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/sentences"
+	"github.com/polycloze/polycloze/text"
+	"github.com/polycloze/polycloze/translator"
+)
+
+type Format string
+
+const (
+	FormatCSV   Format = "csv"   // Can be imported back using `Replay`
+	FormatJSONL Format = "jsonl" // JSON Lines
+	FormatAnki  Format = "anki"  // Anki cloze notes (tab-separated)
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case FormatCSV, FormatJSONL, FormatAnki:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown export format: %v", s)
+	}
+}
+
+// Returns the file extension of the export format.
+func (f Format) Extension() string {
+	if f == FormatAnki {
+		return "txt"
+	}
+	return string(f)
+}
+
+// Calls fn on every event in the review history in chronological order.
+// Only includes cloze reviews. Card review tracks and word states aren't part
+// of the history; they're in the user's data archive instead.
+func forEachHistory[T database.Querier](q T, fn func(ReviewEvent) error) error {
+	query := `
+		SELECT word, reviewed, interval_after > 0, coalesce(grade, 0)
+		FROM history
+		ORDER BY reviewed ASC, rowid ASC
+	`
+	rows, err := q.Query(query)
+	if err != nil {
+		return fmt.Errorf("failed to read review history: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event ReviewEvent
+		var reviewed int64
+		if err := rows.Scan(&event.Word, &reviewed, &event.Correct, &event.Grade); err != nil {
+			return fmt.Errorf("failed to read review history: %w", err)
+		}
+		event.Reviewed = time.Unix(reviewed, 0)
+
+		// The grade is more reliable, because schedulers are free to pick any
+		// interval.
+		if event.Grade.IsValid() {
+			event.Correct = event.Grade.IsCorrect()
+		}
+
+		if err := fn(event); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Writes review history as CSV.
+func ExportCSV[T database.Querier](q T, w io.Writer) error {
+	csvWriter := csv.NewWriter(w)
+	// The header gets skipped by `Replay`.
+	if err := csvWriter.Write([]string{"word", "reviewed", "correct", "grade"}); err != nil {
+		return fmt.Errorf("failed to export reviews: %w", err)
+	}
+	csvWriter.Flush()
+	if err := csvWriter.Error(); err != nil {
+		return fmt.Errorf("failed to export reviews: %w", err)
+	}
+
+	writer := NewReviewWriter(csvWriter)
+	if err := forEachHistory(q, writer.WriteReview); err != nil {
+		return fmt.Errorf("failed to export reviews: %w", err)
+	}
+	return nil
+}
+
+// JSON representation of ReviewEvent.
+type jsonReview struct {
+	Word     string   `json:"word"`
+	Reviewed int64    `json:"reviewed"`
+	Correct  bool     `json:"correct"`
+	Grade    rs.Grade `json:"grade,omitempty"`
+}
+
+// Writes review history as JSON Lines.
+func ExportJSONL[T database.Querier](q T, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	err := forEachHistory(q, func(e ReviewEvent) error {
+		return encoder.Encode(jsonReview{
+			Word:     e.Word,
+			Reviewed: e.Reviewed.Unix(),
+			Correct:  e.Correct,
+			Grade:    e.Grade,
+		})
+	})
+	if err != nil {
+		return fmt.Errorf("failed to export reviews: %w", err)
+	}
+	return nil
+}
+
+// Replaces characters that can't appear inside fields of Anki text files.
+var ankiFieldReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// Returns cloze text with the word blanked.
+// Falls back to a lone cloze deletion if the word isn't in the course.
+func ankiCloze[T database.Querier](q T, word string) (string, string) {
+	cloze := func(s string) string {
+		return "{{c1::" + html.EscapeString(s) + "}}"
+	}
+
+	sentence, err := sentences.PickSentence(q, word)
+	if err != nil {
+		return cloze(word), ""
+	}
+
+	var b strings.Builder
+	found := false
+	for _, token := range sentence.Tokens {
+		if !found && text.Casefold(token) == word {
+			found = true
+			b.WriteString(cloze(token))
+		} else {
+			b.WriteString(html.EscapeString(token))
+		}
+	}
+	if !found {
+		return cloze(word), ""
+	}
+
+	var back string
+	if translation, err := translator.Translate(q, sentence); err == nil {
+		back = html.EscapeString(translation.Text)
+	}
+	return b.String(), back
+}
+
+// Writes cloze notes for every reviewed word, in the order they were learned.
+// The output can be imported into Anki as a text file.
+// Uses example sentences from the course DB if it's attached.
+func ExportAnki[T database.Querier](q T, w io.Writer) error {
+	rows, err := q.Query(`SELECT item FROM review ORDER BY learned ASC, item ASC`)
+	if err != nil {
+		return fmt.Errorf("failed to export reviews: %w", err)
+	}
+
+	// Read all words first, because the connection is needed to look up
+	// sentences.
+	var words []string
+	for rows.Next() {
+		var word string
+		if err := rows.Scan(&word); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to export reviews: %w", err)
+		}
+		words = append(words, word)
+	}
+	rows.Close()
+
+	header := "#separator:tab\n#html:true\n#notetype:Cloze\n#tags:polycloze\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return fmt.Errorf("failed to export reviews: %w", err)
+	}
+	for _, word := range words {
+		front, back := ankiCloze(q, word)
+		line := ankiFieldReplacer.Replace(front) + "\t" + ankiFieldReplacer.Replace(back) + "\n"
+		if _, err := io.WriteString(w, line); err != nil {
+			return fmt.Errorf("failed to export reviews: %w", err)
+		}
+	}
+	return nil
+}
+
+// Writes review history in the given format.
+func Export[T database.Querier](q T, w io.Writer, format Format) error {
+	switch format {
+	case FormatCSV:
+		return ExportCSV(q, w)
+	case FormatJSONL:
+		return ExportJSONL(q, w)
+	case FormatAnki:
+		return ExportAnki(q, w)
+	default:
+		return fmt.Errorf("unknown export format: %v", format)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/polycloze/polycloze/utils"
+)
+
+// Replays CSV into a new DB and exports the result.
+func replayAndExport(t *testing.T, s string) string {
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := Replay(db, strings.NewReader(s)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	b := new(strings.Builder)
+	if err := ExportCSV(db, b); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	return b.String()
+}
+
+func TestExportCSVRoundTrip(t *testing.T) {
+	// Exported CSV should be unchanged after being replayed.
+	t.Parallel()
+
+	input := `word,reviewed,correct
+foo,100000,1
+bar,100000,0,again
+foo,500000,1,easy
+bar,500001,1,hard
+foo,9000000,0
+`
+	first := replayAndExport(t, input)
+	second := replayAndExport(t, first)
+	if first != second {
+		t.Fatal("expected export to round-trip:", first, second)
+	}
+
+	r := testReader(first)
+	_, _ = r.ReadReview() // Skip header.
+	for i := 0; i < 5; i++ {
+		if _, err := r.ReadReview(); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+	if _, err := r.ReadReview(); err == nil {
+		t.Fatal("expected export to contain exactly five reviews:", first)
+	}
+}
+
+func TestExportJSONL(t *testing.T) {
+	t.Parallel()
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	input := "foo,100000,1,easy\nbar,100001,0\n"
+	if err := Replay(db, strings.NewReader(input)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	b := new(strings.Builder)
+	if err := ExportJSONL(db, b); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	var reviews []jsonReview
+	scanner := bufio.NewScanner(strings.NewReader(b.String()))
+	for scanner.Scan() {
+		var review jsonReview
+		if err := json.Unmarshal(scanner.Bytes(), &review); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		reviews = append(reviews, review)
+	}
+
+	if len(reviews) != 2 {
+		t.Fatal("expected two reviews:", reviews)
+	}
+	if reviews[0].Word != "foo" || reviews[0].Reviewed != 100000 || !reviews[0].Correct {
+		t.Fatal("unexpected first review:", reviews[0])
+	}
+	if reviews[1].Word != "bar" || reviews[1].Correct {
+		t.Fatal("unexpected second review:", reviews[1])
+	}
+}
+
+func TestExportAnkiWithoutCourse(t *testing.T) {
+	// Should fall back to lone cloze deletions.
+	t.Parallel()
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := Replay(db, strings.NewReader("foo,100000,1\n")); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	b := new(strings.Builder)
+	if err := ExportAnki(db, b); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if !strings.HasSuffix(b.String(), "{{c1::foo}}\t\n") {
+		t.Fatal("expected cloze note for reviewed word:", b.String())
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Merging imported reviews into existing review data.
+package replay
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/difficulty"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/text"
+)
+
+// Reads all review events from CSV.
+// The first record is skipped if it's invalid, because it may be a header row.
+func ReadEvents(r io.Reader) ([]ReviewEvent, error) {
+	reader := NewReviewReader(csv.NewReader(r))
+
+	var events []ReviewEvent
+	if event, err := reader.ReadReview(); err == nil {
+		events = append(events, event)
+	}
+	for {
+		event, err := reader.ReadReview()
+		if errors.Is(err, io.EOF) {
+			return events, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read reviews: %w", err)
+		}
+		events = append(events, event)
+	}
+}
+
+// Identifies duplicate events.
+type eventKey struct {
+	word     string
+	reviewed int64
+	grade    rs.Grade
+}
+
+// Interleaves events by timestamp and removes duplicates.
+// Events in `a` go first if they have the same timestamp as events in `b`.
+// Words get casefolded.
+func mergeEvents(a, b []ReviewEvent) []ReviewEvent {
+	seen := make(map[eventKey]bool)
+	var events []ReviewEvent
+	for _, event := range append(append([]ReviewEvent{}, a...), b...) {
+		event.Word = text.Casefold(event.Word)
+
+		// Ungraded events are treated as if they have the grade they would've
+		// been saved with.
+		key := eventKey{
+			word:     event.Word,
+			reviewed: event.Reviewed.Unix(),
+			grade:    event.Result().GetGrade(),
+		}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		events = append(events, event)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Reviewed.Unix() < events[j].Reviewed.Unix()
+	})
+	return events
+}
+
+// Deletes review data derived from the review history.
+// Card review tracks (`card_review` and `card_history`) and word states are
+// kept on purpose, because they aren't in the review history and can't be
+// rebuilt from it.
+func resetTx(tx *sql.Tx) error {
+	queries := []string{
+		`DELETE FROM review`,
+		`DELETE FROM history`,
+		`DELETE FROM interval`,
+		`INSERT INTO interval (interval) VALUES (0)`,
+		`DELETE FROM vocabulary_size`,
+		`DELETE FROM vocabulary_size_history`,
+		`DELETE FROM estimated_level`,
+		`DELETE FROM estimated_level_history`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query); err != nil {
+			return fmt.Errorf("failed to reset review data: %w", err)
+		}
+	}
+	return nil
+}
+
+// Replays events in order.
+// Also re-estimates the student's level using results on new words.
+func replayTx(tx *sql.Tx, options RebuildOptions, events []ReviewEvent) error {
+	s := options.scheduler()
+	tuner := difficulty.NewTuner(difficulty.Difficulty{})
+	if options.Thresholds != nil {
+		tuner.Thresholds = *options.Thresholds
+	}
+	seen := make(map[string]bool)
+
+	for _, event := range events {
+		if err := rs.UpdateReviewAtTx(tx, s, event.Result(), event.Reviewed); err != nil {
+			return fmt.Errorf("failed to replay reviews: %w", err)
+		}
+
+		// Only tune difficulty based on new words.
+		if seen[event.Word] {
+			continue
+		}
+		seen[event.Word] = true
+
+		tuner.Update(event.Correct)
+		if err := difficulty.UpdateAtTx(tx, tuner.Difficulty, event.Reviewed); err != nil {
+			return fmt.Errorf("failed to replay reviews: %w", err)
+		}
+	}
+	return nil
+}
+
+// Resets review data and replays the events in a single transaction.
+func rebuild[T database.Querier](q T, options RebuildOptions, events []ReviewEvent) error {
+	tx, err := q.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if err := resetTx(tx); err != nil {
+		return err
+	}
+	if err := replayTx(tx, options, events); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Reads the review history in chronological order.
+func readHistory[T database.Querier](q T) ([]ReviewEvent, error) {
+	var events []ReviewEvent
+	err := forEachHistory(q, func(e ReviewEvent) error {
+		events = append(events, e)
+		return nil
+	})
+	return events, err
+}
+
+// Imports review data from CSV file and merges it with existing reviews.
+// The imported events are interleaved with the review history by timestamp,
+// and identical events are only counted once.
+// The review schedule, interval stats, vocabulary size and estimated level are
+// then rebuilt from the merged history using the scheduler.
+func Merge[T database.Querier](q T, s rs.Scheduler, r io.Reader) error {
+	imported, err := ReadEvents(r)
+	if err != nil {
+		return fmt.Errorf("failed to merge reviews: %w", err)
+	}
+
+	existing, err := readHistory(q)
+	if err != nil {
+		return fmt.Errorf("failed to merge reviews: %w", err)
+	}
+	events := mergeEvents(existing, imported)
+
+	if err := rebuild(q, RebuildOptions{Scheduler: s}, events); err != nil {
+		return fmt.Errorf("failed to merge reviews: %w", err)
+	}
+	return nil
+}
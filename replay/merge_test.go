@@ -0,0 +1,125 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package replay
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/utils"
+)
+
+// Returns contents of the review table for comparison.
+func dumpReviews(t *testing.T, db *sql.DB) string {
+	query := `SELECT item, learned, reviewed, interval FROM review ORDER BY item`
+	rows, err := db.Query(query)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer rows.Close()
+
+	b := new(strings.Builder)
+	for rows.Next() {
+		var item string
+		var learned, reviewed, interval int64
+		if err := rows.Scan(&item, &learned, &reviewed, &interval); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		fmt.Fprintln(b, item, learned, reviewed, interval)
+	}
+	return b.String()
+}
+
+func TestMergeEqualsReplayOfMergedHistory(t *testing.T) {
+	// Merging should give the same result as replaying both histories in
+	// chronological order into an empty DB.
+	t.Parallel()
+
+	existing := "foo,100000,1\nbar,300000,0\nfoo,500000,1\n"
+	imported := "bar,200000,1\nfoo,500000,1\nbaz,600000,1\n"
+	merged := "foo,100000,1\nbar,200000,1\nbar,300000,0\nfoo,500000,1\nbaz,600000,1\n"
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+	if err := Replay(db, strings.NewReader(existing)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := Merge(db, rs.DefaultScheduler{}, strings.NewReader(imported)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	expected := utils.TestingDatabase()
+	defer expected.Close()
+	if err := Replay(expected, strings.NewReader(merged)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if a, b := dumpReviews(t, db), dumpReviews(t, expected); a != b {
+		t.Fatal("expected merged reviews to match replayed reviews:", a, b)
+	}
+
+	var a, b strings.Builder
+	if err := ExportCSV(db, &a); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := ExportCSV(expected, &b); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if a.String() != b.String() {
+		t.Fatal("expected merged history to match replayed history:", a.String(), b.String())
+	}
+}
+
+func TestMergeDeduplicates(t *testing.T) {
+	// Merging the same file twice shouldn't change anything.
+	t.Parallel()
+
+	history := "foo,100000,1,good\nbar,200000,0,again\nfoo,500000,1,easy\n"
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+	if err := Replay(db, strings.NewReader(history)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	before := dumpReviews(t, db)
+
+	if err := Merge(db, rs.DefaultScheduler{}, strings.NewReader(history)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if after := dumpReviews(t, db); before != after {
+		t.Fatal("expected reviews to be unchanged:", before, after)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM history`).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 3 {
+		t.Fatal("expected duplicate events to be removed:", count)
+	}
+}
+
+func TestMergeRebuildsVocabularySize(t *testing.T) {
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+	if err := Replay(db, strings.NewReader("foo,100000,1\n")); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := Merge(db, rs.DefaultScheduler{}, strings.NewReader("bar,200000,1\n")); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	var size int
+	if err := db.QueryRow(`SELECT v FROM vocabulary_size`).Scan(&size); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if size != 2 {
+		t.Fatal("expected vocabulary size to include merged words:", size)
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Rebuilding review data from the review history.
+package replay
+
+import (
+	"fmt"
+
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/wilson"
+)
+
+type RebuildOptions struct {
+	// Scheduler used to recompute the review schedule.
+	// Uses the default scheduler if nil.
+	Scheduler rs.Scheduler
+
+	// Thresholds for re-estimating the student's level.
+	// Uses `wilson.DefaultThresholds` if nil.
+	Thresholds *wilson.Thresholds
+}
+
+func (o RebuildOptions) scheduler() rs.Scheduler {
+	if o.Scheduler == nil {
+		return rs.DefaultScheduler{}
+	}
+	return o.Scheduler
+}
+
+// Recreates the review, interval, vocabulary size and estimated level tables
+// from the review history.
+// The history itself gets rewritten with the new intervals.
+// Card review tracks and word states are left alone (see resetTx).
+func Rebuild[T database.Querier](q T, options RebuildOptions) error {
+	events, err := readHistory(q)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild review data: %w", err)
+	}
+
+	// Normalizes words and removes duplicate events, in case the history was
+	// written by buggy code.
+	events = mergeEvents(events, nil)
+
+	if err := rebuild(q, options, events); err != nil {
+		return fmt.Errorf("failed to rebuild review data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package replay
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/utils"
+)
+
+func TestRebuildRepairsReviews(t *testing.T) {
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+	input := "foo,100000,1\nbar,200000,0\nfoo,500000,1,easy\nbar,600000,1,hard\n"
+	if err := Replay(db, strings.NewReader(input)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	before := dumpReviews(t, db)
+
+	// Damage review data.
+	queries := []string{
+		`UPDATE review SET interval = 9999`,
+		`DELETE FROM interval`,
+		`DELETE FROM vocabulary_size`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	if err := Rebuild(db, RebuildOptions{}); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if after := dumpReviews(t, db); before != after {
+		t.Fatal("expected reviews to be restored:", before, after)
+	}
+
+	var size int
+	if err := db.QueryRow(`SELECT v FROM vocabulary_size`).Scan(&size); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if size != 2 {
+		t.Fatal("expected vocabulary size to be restored:", size)
+	}
+}
+
+// Schedules every review one week later.
+type weeklyScheduler struct{}
+
+func (weeklyScheduler) NextReview(tx *sql.Tx, review *rs.Review, grade rs.Grade, now time.Time) (rs.Review, error) {
+	return rs.Review{Reviewed: now, Interval: 7 * 24 * time.Hour}, nil
+}
+
+func (weeklyScheduler) UpdateStats(tx *sql.Tx, review *rs.Review, grade rs.Grade, now time.Time) error {
+	return nil
+}
+
+func (weeklyScheduler) AutoTune(tx *sql.Tx) error {
+	return nil
+}
+
+func TestRebuildWithScheduler(t *testing.T) {
+	// Should recompute schedule using the given scheduler.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+	if err := Replay(db, strings.NewReader("foo,100000,1\nbar,200000,0\n")); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if err := Rebuild(db, RebuildOptions{Scheduler: weeklyScheduler{}}); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	var count int
+	query := `SELECT count(*) FROM review WHERE interval = 168`
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 2 {
+		t.Fatal("expected all reviews to be rescheduled:", count)
+	}
+
+	query = `SELECT count(*) FROM history`
+	if err := db.QueryRow(query).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 2 {
+		t.Fatal("expected history to be preserved:", count)
+	}
+}
+
+func TestRebuildKeepsCardReviewsAndWordStates(t *testing.T) {
+	// Card review tracks and word states aren't in the review history, so
+	// rebuilding and merging shouldn't delete them.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+	if err := Replay(db, strings.NewReader("foo,100000,1\nbar,200000,0\n")); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	query := `
+		INSERT INTO card_review (kind, item, learned, reviewed, interval, grade)
+		VALUES ('choice', 'foo', 100000, 100000, 24, 3)
+	`
+	if _, err := db.Exec(query); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := rs.SetWordState(db, "bar", rs.Suspended); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if err := Rebuild(db, RebuildOptions{}); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := Merge(db, nil, strings.NewReader("baz,300000,1\n")); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	for _, table := range []string{"card_review", "card_history", "word_state"} {
+		var count int
+		if err := db.QueryRow(`SELECT count(*) FROM ` + table).Scan(&count); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		if count != 1 {
+			t.Fatal("expected rows to be kept:", table, count)
+		}
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"os"
 
 	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
 	"github.com/polycloze/polycloze/word_scheduler"
 )
 
@@ -56,10 +57,10 @@ func Replay[T database.Querier](q T, r io.Reader) error {
 	// Ignore first error (it may be a header row), but don't ignore further
 	// errors.
 	if review, err := reader.ReadReview(); err == nil {
-		if err := word_scheduler.UpdateWordAt(
+		if err := word_scheduler.SaveWordAt(
 			q,
-			review.Word,
-			review.Correct,
+			rs.DefaultScheduler{},
+			review.Result(),
 			review.Reviewed,
 		); err != nil {
 			return fmt.Errorf("failed to import review: %w", err)
@@ -73,10 +74,10 @@ func Replay[T database.Querier](q T, r io.Reader) error {
 		if err != nil {
 			break
 		}
-		if err := word_scheduler.UpdateWordAt(
+		if err := word_scheduler.SaveWordAt(
 			q,
-			review.Word,
-			review.Correct,
+			rs.DefaultScheduler{},
+			review.Result(),
 			review.Reviewed,
 		); err != nil {
 			return fmt.Errorf("failed to import review: %w", err)
@@ -9,22 +9,38 @@ import (
 	"fmt"
 	"strconv"
 	"time"
+
+	rs "github.com/polycloze/polycloze/review_scheduler"
 )
 
 type ReviewEvent struct {
 	Word     string
 	Reviewed time.Time
 	Correct  bool
+	Grade    rs.Grade // Optional
+}
+
+// Returns review result represented by the event.
+func (e ReviewEvent) Result() rs.Result {
+	return rs.Result{
+		Word:    e.Word,
+		Correct: e.Correct,
+		Grade:   e.Grade,
+	}
 }
 
 // Turns the event into a CSV record.
+// The grade column is only included if the event has a valid grade.
 func (e ReviewEvent) Record() []string {
 	reviewed := strconv.FormatInt(e.Reviewed.Unix(), 10)
 	correct := "1"
 	if !e.Correct {
 		correct = "0"
 	}
-	return []string{e.Word, reviewed, correct}
+	if !e.Grade.IsValid() {
+		return []string{e.Word, reviewed, correct}
+	}
+	return []string{e.Word, reviewed, correct, e.Grade.String()}
 }
 
 type ReviewReader struct {
@@ -35,12 +51,18 @@ func NewReviewReader(r *csv.Reader) *ReviewReader {
 	return &ReviewReader{csvReader: r}
 }
 
+// Reads review from CSV.
+// Records have the following fields: word, reviewed, correct, and an optional
+// grade.
 func (r *ReviewReader) ReadReview() (ReviewEvent, error) {
+	// Records may or may not have a grade column.
+	r.csvReader.FieldsPerRecord = -1
+
 	record, err := r.csvReader.Read()
 	if err != nil {
 		return ReviewEvent{}, fmt.Errorf("failed to read review from CSV: %w", err)
 	}
-	if len(record) != 3 {
+	if len(record) != 3 && len(record) != 4 {
 		return ReviewEvent{}, errors.New(
 			"failed to read review from CSV: incorrect number of fields",
 		)
@@ -63,10 +85,22 @@ func (r *ReviewReader) ReadReview() (ReviewEvent, error) {
 		)
 	}
 
+	var grade rs.Grade
+	if len(record) == 4 && record[3] != "" {
+		grade, err = rs.ParseGrade(record[3])
+		if err != nil {
+			return ReviewEvent{}, fmt.Errorf("failed to read review from CSV: %w", err)
+		}
+		if err := rs.CheckGrade(grade, correct); err != nil {
+			return ReviewEvent{}, fmt.Errorf("failed to read review from CSV: %w", err)
+		}
+	}
+
 	return ReviewEvent{
 		Word:     record[0],
 		Reviewed: time.Unix(i, 0),
 		Correct:  correct,
+		Grade:    grade,
 	}, nil
 }
 
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	rs "github.com/polycloze/polycloze/review_scheduler"
 )
 
 func testReader(s string) *ReviewReader {
@@ -147,3 +149,51 @@ func TestReadReviewReviewed(t *testing.T) {
 		t.Fatal("expected record.Reviewed to be the same:", a, b)
 	}
 }
+
+func TestReadReviewGrade(t *testing.T) {
+	t.Parallel()
+
+	r := testReader(`foo,0,1,hard
+bar,0,0
+baz,0,1,again
+`)
+
+	e, err := r.ReadReview()
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if e.Grade != rs.Hard {
+		t.Fatal("expected record.Grade to be hard:", e.Grade)
+	}
+
+	// Grade column is optional.
+	e, err = r.ReadReview()
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if e.Grade != 0 || e.Result().GetGrade() != rs.Again {
+		t.Fatal("expected missing grade to be derived from correct value:", e.Grade)
+	}
+
+	// Grade has to agree with correct value.
+	if _, err := r.ReadReview(); err == nil {
+		t.Fatal("expected mismatched grade to cause error")
+	}
+}
+
+func TestWriteReviewGrade(t *testing.T) {
+	t.Parallel()
+
+	reviews := []ReviewEvent{
+		{Word: "foo", Reviewed: time.Unix(0, 0), Correct: true, Grade: rs.Easy},
+	}
+	r := testReader(writeCSV(reviews))
+
+	e, err := r.ReadReview()
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if e != reviews[0] {
+		t.Fatal("expected review to round-trip:", e, reviews[0])
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Review tracks of different card kinds.
+package review_scheduler
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+// Kind of flashcard.
+// Each kind has its own review track.
+type Kind string
+
+const (
+	// Uses the `review` table.
+	Cloze Kind = "cloze"
+
+	// The rest use the `card_review` table.
+	Production Kind = "production" // Translate the L1 sentence into L2
+	Choice     Kind = "choice"     // Pick the word from a list
+	Scramble   Kind = "scramble"   // Put words in the correct order
+)
+
+var kinds = []Kind{Cloze, Production, Choice, Scramble}
+
+// Checks if kind is one of the defined kinds.
+// The empty kind is treated as Cloze.
+func (k Kind) IsValid() bool {
+	if k == "" {
+		return true
+	}
+	for _, kind := range kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks if the kind uses the `review` table.
+func (k Kind) IsCloze() bool {
+	return k == "" || k == Cloze
+}
+
+// Parses card kind.
+// Returns Cloze if s is empty.
+func ParseKind(s string) (Kind, error) {
+	kind := Kind(s)
+	if !kind.IsValid() {
+		return "", fmt.Errorf("invalid card kind: %v", s)
+	}
+	if kind == "" {
+		return Cloze, nil
+	}
+	return kind, nil
+}
+
+// Gets most recent review of item in the card kind's track.
+// Returns nil without errors if the item hasn't been reviewed yet.
+func mostRecentCardReview(tx *sql.Tx, kind Kind, item string) (*Review, error) {
+	query := `SELECT interval, reviewed FROM card_review WHERE kind = ? AND item = ?`
+
+	var interval time.Duration
+	var reviewed int64
+	err := tx.QueryRow(query, kind, item).Scan(&interval, &reviewed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Review{
+		Reviewed: time.Unix(reviewed, 0),
+		Interval: interval * time.Hour,
+	}, nil
+}
+
+// Saves review result in the card kind's track.
+// Only the cloze track updates scheduler stats, so that auto-tuning isn't
+// skewed by easier or harder card kinds.
+func updateCardReviewAtTx(tx *sql.Tx, s Scheduler, result Result, now time.Time) error {
+	review, err := mostRecentCardReview(tx, result.Kind, result.Word)
+	if err != nil {
+		return fmt.Errorf("failed to update card review: %w", err)
+	}
+
+	grade := result.GetGrade()
+	next, err := s.NextReview(tx, review, grade, now)
+	if err != nil {
+		return fmt.Errorf("failed to update card review: %w", err)
+	}
+
+	query := `
+		INSERT INTO card_review (kind, item, interval, learned, reviewed, grade)
+		VALUES (@kind, @item, @interval, @now, @now, @grade)
+		ON CONFLICT (kind, item) DO UPDATE SET
+			interval = excluded.interval,
+			reviewed = excluded.reviewed,
+			grade = excluded.grade
+	`
+	_, err = tx.Exec(
+		query,
+		sql.Named("kind", result.Kind),
+		sql.Named("item", result.Word),
+		sql.Named("interval", int64(next.Interval.Hours())),
+		sql.Named("now", now.Unix()),
+		sql.Named("grade", int(grade)),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update card review: %w", err)
+	}
+	return nil
+}
+
+// Returns items due for review in the card kind's track, no more than count.
+// Only items that satisfy the predicate are included in the result.
+func ScheduleCardReviewNowWith[T database.Querier](
+	q T,
+	kind Kind,
+	count int,
+	pred func(item string) bool,
+) ([]string, error) {
+	if kind.IsCloze() {
+		return ScheduleReviewNowWith(q, count, pred)
+	}
+
+	query := `
+		SELECT item FROM card_review
+		WHERE kind = ? AND due <= ?
+			AND item NOT IN (SELECT item FROM hidden_item)
+		ORDER BY due
+	`
+	rows, err := q.Query(query, kind, time.Now().Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []string
+	for rows.Next() && len(items) < count {
+		var item string
+		if err := rows.Scan(&item); err != nil {
+			return nil, err
+		}
+		if pred(item) {
+			items = append(items, item)
+		}
+	}
+	return items, nil
+}
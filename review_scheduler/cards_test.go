@@ -0,0 +1,73 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package review_scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/utils"
+)
+
+func TestParseKind(t *testing.T) {
+	t.Parallel()
+
+	kind, err := ParseKind("")
+	if err != nil || kind != Cloze {
+		t.Fatal("expected empty kind to be cloze:", kind, err)
+	}
+	if _, err := ParseKind("essay"); err == nil {
+		t.Fatal("expected error for unknown card kind")
+	}
+}
+
+func TestCardTracksAreSeparate(t *testing.T) {
+	// Reviews of other card kinds shouldn't affect the cloze track.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	now := time.Now()
+	result := Result{Word: "foo", Correct: false, Kind: Choice}
+	if err := SaveReviewAt(db, DefaultScheduler{}, result, now); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	items, err := ScheduleReviewNow(db, 100)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(items) > 0 {
+		t.Fatal("expected cloze track to be empty:", items)
+	}
+
+	pred := func(_ string) bool { return true }
+	for _, kind := range []Kind{Choice, Production} {
+		items, err := ScheduleCardReviewNowWith(db, kind, 100, pred)
+		if err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+
+		expected := 0
+		if kind == Choice {
+			expected = 1
+		}
+		if len(items) != expected {
+			t.Fatal("expected different number of items in track:", kind, items)
+		}
+	}
+}
+
+func TestInvalidKind(t *testing.T) {
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	result := Result{Word: "foo", Correct: true, Kind: "essay"}
+	if err := SaveReviewAt(db, DefaultScheduler{}, result, time.Now()); err == nil {
+		t.Fatal("expected error for invalid card kind")
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Leech detection.
+package review_scheduler
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+// Number of consecutive failures before an item counts as a leech.
+const DefaultLeechThreshold = 8
+
+// Optional interface for schedulers with a custom leech threshold.
+type LeechDetector interface {
+	LeechThreshold() int
+}
+
+// Scheduler wrapper with a custom leech threshold.
+type LeechScheduler struct {
+	Scheduler
+	Threshold int
+}
+
+func (s LeechScheduler) LeechThreshold() int {
+	return s.Threshold
+}
+
+// Returns the scheduler's leech threshold.
+func leechThreshold(s Scheduler) int {
+	if d, ok := s.(LeechDetector); ok && d.LeechThreshold() > 0 {
+		return d.LeechThreshold()
+	}
+	return DefaultLeechThreshold
+}
+
+// Counts failed reviews of item since the last successful review.
+const consecutiveFailures = `
+	SELECT count(*) FROM history
+	WHERE word = @item AND interval_after = 0 AND reviewed > coalesce(
+		(SELECT max(reviewed) FROM history WHERE word = @item AND interval_after > 0),
+		0
+	)
+`
+
+func scanFailures(row *sql.Row) (int, error) {
+	var count int
+	if err := row.Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count consecutive failures: %w", err)
+	}
+	return count, nil
+}
+
+// Counts failed reviews of item since the last successful review.
+func ConsecutiveFailures[T database.Querier](q T, item string) (int, error) {
+	return scanFailures(q.QueryRow(consecutiveFailures, sql.Named("item", item)))
+}
+
+// Checks if the item has failed at least threshold times in a row.
+func isLeechTx(tx *sql.Tx, item string, threshold int) (bool, error) {
+	count, err := scanFailures(tx.QueryRow(consecutiveFailures, sql.Named("item", item)))
+	if err != nil {
+		return false, err
+	}
+	return count >= threshold, nil
+}
+
+type Leech struct {
+	Item     string
+	Failures int    // Number of consecutive failures
+	State    string // Empty if the student hasn't acted on the leech yet
+}
+
+// Lists leeches, most failed first.
+func Leeches[T database.Querier](q T, threshold int) ([]Leech, error) {
+	query := `
+		SELECT word, count(*) AS failures, coalesce(state, '') FROM history AS h
+		LEFT JOIN word_state ON (word_state.item = h.word)
+		WHERE interval_after = 0 AND reviewed > coalesce(
+			(
+				SELECT max(reviewed) FROM history
+				WHERE word = h.word AND interval_after > 0
+			),
+			0
+		)
+		GROUP BY word
+		HAVING failures >= ?
+		ORDER BY failures DESC, word ASC
+	`
+	rows, err := q.Query(query, threshold)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leeches: %w", err)
+	}
+	defer rows.Close()
+
+	var leeches []Leech
+	for rows.Next() {
+		var leech Leech
+		if err := rows.Scan(&leech.Item, &leech.Failures, &leech.State); err != nil {
+			return nil, fmt.Errorf("failed to list leeches: %w", err)
+		}
+		leeches = append(leeches, leech)
+	}
+	return leeches, nil
+}
+
+// Returns JSON array of leeches.
+// Useful for filtering queries with `json_each`.
+func LeechesJSON[T database.Querier](q T, threshold int) (string, error) {
+	leeches, err := Leeches(q, threshold)
+	if err != nil {
+		return "", err
+	}
+
+	items := make([]string, 0, len(leeches))
+	for _, leech := range leeches {
+		items = append(items, leech.Item)
+	}
+	bytes, err := json.Marshal(items)
+	if err != nil {
+		return "", fmt.Errorf("failed to list leeches: %w", err)
+	}
+	return string(bytes), nil
+}
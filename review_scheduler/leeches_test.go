@@ -0,0 +1,114 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package review_scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/utils"
+)
+
+func TestLeeches(t *testing.T) {
+	// Only consecutive failures should count.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	s := LeechScheduler{Scheduler: DefaultScheduler{}, Threshold: 3}
+	now := time.Now()
+	results := []Result{
+		{Word: "foo", Correct: false},
+		{Word: "foo", Correct: true},
+		{Word: "foo", Correct: false},
+		{Word: "foo", Correct: false},
+		{Word: "bar", Correct: false},
+	}
+	for i, result := range results {
+		at := now.Add(time.Duration(i) * time.Hour)
+		if err := SaveReviewAt(db, s, result, at); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	failures, err := ConsecutiveFailures(db, "foo")
+	if err != nil || failures != 2 {
+		t.Fatal("expected two consecutive failures:", failures, err)
+	}
+
+	if leeches, err := Leeches(db, s.Threshold); err != nil || len(leeches) != 0 {
+		t.Fatal("expected no leeches:", leeches, err)
+	}
+
+	result := Result{Word: "foo", Correct: false}
+	if err := SaveReviewAt(db, s, result, now.Add(24*time.Hour)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	leeches, err := Leeches(db, s.Threshold)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(leeches) != 1 || leeches[0].Item != "foo" || leeches[0].Failures != 3 {
+		t.Fatal("expected foo to be a leech:", leeches)
+	}
+}
+
+func TestSuspendedWordsArentScheduled(t *testing.T) {
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := UpdateReview(db, "foo", false); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := SetWordState(db, "foo", Suspended); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	items, err := ScheduleReviewNow(db, 100)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(items) > 0 {
+		t.Fatal("expected suspended word to not be scheduled:", items)
+	}
+
+	// Unsuspend.
+	if err := SetWordState(db, "foo", ""); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	items, err = ScheduleReviewNow(db, 100)
+	if err != nil || len(items) != 1 {
+		t.Fatal("expected unsuspended word to be scheduled:", items, err)
+	}
+}
+
+func TestResetWord(t *testing.T) {
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := UpdateReview(db, "foo", false); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := SetWordState(db, "foo", Drilling); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := ResetWord(db, "foo"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	failures, err := ConsecutiveFailures(db, "foo")
+	if err != nil || failures != 0 {
+		t.Fatal("expected review history to be deleted:", failures, err)
+	}
+	items, err := ScheduleReviewNow(db, 100)
+	if err != nil || len(items) != 0 {
+		t.Fatal("expected word to be removed from reviews:", items, err)
+	}
+}
@@ -3,8 +3,111 @@
 
 package review_scheduler
 
+import (
+	"errors"
+	"fmt"
+)
+
+var ErrGradeMismatch = errors.New("grade doesn't match correct value")
+
+// How well the student answered.
+// Clients should grade answers that needed hints, had typo-level mistakes or
+// took too long as Hard.
+type Grade int
+
+const (
+	// Zero value means the grade wasn't specified.
+	Again Grade = iota + 1 // Incorrect answer
+	Hard                   // Correct, but with some difficulty
+	Good                   // Correct
+	Easy                   // Correct, and too easy
+)
+
+var gradeNames = map[Grade]string{
+	Again: "again",
+	Hard:  "hard",
+	Good:  "good",
+	Easy:  "easy",
+}
+
+// Checks if grade is one of the defined grades.
+func (g Grade) IsValid() bool {
+	_, ok := gradeNames[g]
+	return ok
+}
+
+// Checks if grade counts as a correct answer.
+func (g Grade) IsCorrect() bool {
+	return g.IsValid() && g != Again
+}
+
+func (g Grade) String() string {
+	if name, ok := gradeNames[g]; ok {
+		return name
+	}
+	return fmt.Sprintf("Grade(%d)", int(g))
+}
+
+func (g Grade) MarshalText() ([]byte, error) {
+	if !g.IsValid() {
+		return nil, fmt.Errorf("invalid grade: %d", int(g))
+	}
+	return []byte(g.String()), nil
+}
+
+func (g *Grade) UnmarshalText(text []byte) error {
+	grade, err := ParseGrade(string(text))
+	if err != nil {
+		return err
+	}
+	*g = grade
+	return nil
+}
+
+// Parses grade name.
+func ParseGrade(s string) (Grade, error) {
+	for grade, name := range gradeNames {
+		if name == s {
+			return grade, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid grade: %v", s)
+}
+
+// Returns the fixed grade for boolean results.
+func GradeOf(correct bool) Grade {
+	if correct {
+		return Good
+	}
+	return Again
+}
+
+// Checks if the grade agrees with the correct value.
+// Unspecified grades always agree.
+func CheckGrade(grade Grade, correct bool) error {
+	if grade != 0 && grade.IsCorrect() != correct {
+		return ErrGradeMismatch
+	}
+	return nil
+}
+
 // Review results
 type Result struct {
 	Word    string `json:"word"`
 	Correct bool   `json:"correct"`
+
+	// Optional. If omitted, the grade is derived from `Correct`.
+	Grade Grade `json:"grade,omitempty"`
+
+	// Optional. Review track of the result (cloze if omitted).
+	Kind Kind `json:"kind,omitempty"`
+}
+
+// Returns grade of the result.
+// Boolean results get mapped to fixed grades.
+func (r Result) GetGrade() Grade {
+	if r.Grade.IsValid() {
+		return r.Grade
+	}
+	return GradeOf(r.Correct)
 }
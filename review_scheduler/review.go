@@ -23,10 +23,13 @@ func (r Review) Correct() bool {
 }
 
 // Calculates interval for next review.
-func calculateInterval(tx *sql.Tx, review *Review, correct bool, now time.Time) (time.Duration, error) {
-	if !correct {
+// Again resets the interval, Hard keeps it, Good advances it by one step and
+// Easy advances it by two steps.
+func calculateInterval(tx *sql.Tx, review *Review, grade Grade, now time.Time) (time.Duration, error) {
+	if !grade.IsCorrect() {
 		return 0, nil
 	}
+
 	reviewed := now
 	if review != nil {
 		if now.Before(review.Due()) {
@@ -34,22 +37,30 @@ func calculateInterval(tx *sql.Tx, review *Review, correct bool, now time.Time)
 			println("user crammed :(")
 			return review.Interval, nil
 		}
+		if grade == Hard && review.Interval > 0 {
+			return review.Interval, nil
+		}
 		reviewed = review.Reviewed
 	}
 
 	interval := now.Sub(reviewed) // this is greater than review.Interval
-	return nextInterval(tx, interval)
+	next, err := nextInterval(tx, interval)
+	if err != nil || grade != Easy {
+		return next, err
+	}
+	return nextInterval(tx, next)
 }
 
 // Computes next review schedule.
 // If review is nil, creates Review with default values for initial review.
 // now should usually be time.Now.UTC().
-func nextReview(tx *sql.Tx, review *Review, correct bool, now time.Time) (Review, error) {
+func nextReview(tx *sql.Tx, review *Review, grade Grade, now time.Time) (Review, error) {
 	var r Review
-	interval, err := calculateInterval(tx, review, correct, now)
+	interval, err := calculateInterval(tx, review, grade, now)
 	if err != nil {
 		return r, err
 	}
+
 	r.Reviewed = now
 	r.Interval = interval
 	return r, nil
@@ -16,7 +16,11 @@ import (
 // Returns items due for review, no more than count.
 // Pass a negative count if you want to get all due items.
 func ScheduleReview[T database.Querier](q T, due time.Time, count int) ([]string, error) {
-	query := `SELECT item FROM review WHERE due <= ? ORDER BY due LIMIT ?`
+	query := `
+		SELECT item FROM review
+		WHERE due <= ? AND item NOT IN (SELECT item FROM hidden_item)
+		ORDER BY due LIMIT ?
+	`
 	rows, err := q.Query(query, due.Unix(), count)
 	if err != nil {
 		return nil, err
@@ -42,7 +46,11 @@ func ScheduleReviewNow[T database.Querier](q T, count int) ([]string, error) {
 // Same as ScheduleReviewNowWith, but takes a predicate argument.
 // Only items that satisfy the predicate are included in the result.
 func ScheduleReviewNowWith[T database.Querier](q T, count int, pred func(item string) bool) ([]string, error) {
-	query := `SELECT item FROM review WHERE due <= ? ORDER BY due`
+	query := `
+		SELECT item FROM review
+		WHERE due <= ? AND item NOT IN (SELECT item FROM hidden_item)
+		ORDER BY due
+	`
 	rows, err := q.Query(query, time.Now().Unix())
 	if err != nil {
 		return nil, err
@@ -63,7 +71,8 @@ func ScheduleReviewNowWith[T database.Querier](q T, count int, pred func(item st
 }
 
 // Gets most recent review of item.
-func mostRecentReview(tx *sql.Tx, item string) (*Review, error) {
+// Returns nil without errors if the item hasn't been reviewed yet.
+func MostRecentReview(tx *sql.Tx, item string) (*Review, error) {
 	query := `SELECT interval, reviewed FROM review WHERE item = ?`
 	row := tx.QueryRow(query, item)
 	var review Review
@@ -86,75 +95,97 @@ func mostRecentReview(tx *sql.Tx, item string) (*Review, error) {
 	return &review, nil
 }
 
-// Same as `UpdateReviewAt`, but explicitly takes an `*sql.Tx`.
-func UpdateReviewAtTx(tx *sql.Tx, result Result, now time.Time) error {
-	review, err := mostRecentReview(tx, result.Word)
+// Same as `UpdateReviewAt`, but explicitly takes an `*sql.Tx` and the
+// scheduler to use.
+func UpdateReviewAtTx(tx *sql.Tx, s Scheduler, result Result, now time.Time) error {
+	if !result.Kind.IsValid() {
+		return fmt.Errorf("failed to update review: invalid card kind: %v", result.Kind)
+	}
+	if !result.Kind.IsCloze() {
+		return updateCardReviewAtTx(tx, s, result, now)
+	}
+
+	review, err := MostRecentReview(tx, result.Word)
 	if err != nil {
 		return fmt.Errorf("failed to update review: %w", err)
 	}
 
-	if review == nil || !now.Before(review.Due()) {
-		// Only update interval stats if the student didn't cram
-		if err := updateIntervalStats(tx, review, result.Correct); err != nil {
+	// Leeches would drag down the interval stats used for auto-tuning.
+	leech, err := isLeechTx(tx, result.Word, leechThreshold(s))
+	if err != nil {
+		return fmt.Errorf("failed to update review: %w", err)
+	}
+
+	grade := result.GetGrade()
+	if !leech {
+		if err := s.UpdateStats(tx, review, grade, now); err != nil {
 			return fmt.Errorf("failed to update review: %w", err)
 		}
 	}
 
-	next, err := nextReview(tx, review, result.Correct, now)
+	next, err := s.NextReview(tx, review, grade, now)
 	if err != nil {
 		return fmt.Errorf("failed to update review: %w", err)
 	}
 
 	query := `
-		INSERT INTO review (item, interval, learned, reviewed)
-		VALUES (@item, @interval, @now, @now)
+		INSERT INTO review (item, interval, learned, reviewed, grade)
+		VALUES (@item, @interval, @now, @now, @grade)
 		ON CONFLICT (item) DO UPDATE SET
 			interval = excluded.interval,
-			reviewed = excluded.reviewed
+			reviewed = excluded.reviewed,
+			grade = excluded.grade
 	`
 	_, err = tx.Exec(
 		query,
 		sql.Named("item", result.Word),
 		sql.Named("interval", int64(next.Interval.Hours())),
 		sql.Named("now", now.Unix()),
+		sql.Named("grade", int(grade)),
 	)
 	if err != nil {
 		return fmt.Errorf("failed to update review: %w", err)
 	}
-	if err := autoTune(tx); err != nil {
+	if err := s.AutoTune(tx); err != nil {
 		return fmt.Errorf("failed to update review: %w", err)
 	}
 
 	return nil
 }
 
-// Updates review status of item.
-func UpdateReviewAt[T database.Querier](q T, item string, correct bool, now time.Time) error {
+// Saves review result using the given scheduler.
+func SaveReviewAt[T database.Querier](q T, s Scheduler, result Result, now time.Time) error {
 	tx, err := q.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to update review: %w", err)
 	}
+	defer func() { _ = tx.Rollback() }()
 
-	result := Result{
-		Word:    item,
-		Correct: correct,
-	}
-	if err := UpdateReviewAtTx(tx, result, now); err != nil {
+	if err := UpdateReviewAtTx(tx, s, result, now); err != nil {
 		return fmt.Errorf("failed to update review: %w", err)
 	}
-
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to update review: %w", err)
 	}
 	return nil
 }
 
+// Updates review status of item.
+// Uses the default scheduler.
+func UpdateReviewAt[T database.Querier](q T, item string, correct bool, now time.Time) error {
+	result := Result{
+		Word:    item,
+		Correct: correct,
+	}
+	return SaveReviewAt(q, DefaultScheduler{}, result, now)
+}
+
 func UpdateReview[T database.Querier](q T, item string, correct bool) error {
 	return UpdateReviewAt(q, item, correct, time.Now().UTC())
 }
 
 // Saves reviews in bulk.
-func BulkSaveReviews[T database.Querier](q T, reviews []Result, now time.Time) error {
+func BulkSaveReviews[T database.Querier](q T, s Scheduler, reviews []Result, now time.Time) error {
 	tx, err := q.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to save reviews in bulk: %w", err)
@@ -162,7 +193,7 @@ func BulkSaveReviews[T database.Querier](q T, reviews []Result, now time.Time) e
 
 	// Best-effort save.
 	for _, review := range reviews {
-		_ = UpdateReviewAtTx(tx, review, now)
+		_ = UpdateReviewAtTx(tx, s, review, now)
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -4,6 +4,8 @@
 package review_scheduler
 
 import (
+	"database/sql"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -238,3 +240,116 @@ func TestUpdateReviewAt(t *testing.T) {
 		)
 	}
 }
+
+// Returns interval of item in the review table.
+func queryItemInterval(t *testing.T, db *sql.DB, item string) time.Duration {
+	var interval time.Duration
+	query := `SELECT interval FROM review WHERE item = ?`
+	if err := db.QueryRow(query, item).Scan(&interval); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	return interval * time.Hour
+}
+
+func TestGradeChangesIntervalByDifferentAmounts(t *testing.T) {
+	// Hard < Good < Easy after the same review history.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	now := time.Now().UTC()
+	items := []string{"hard", "good", "easy"}
+	for _, item := range items {
+		if err := UpdateReviewAt(db, item, true, now); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	later := now.Add(3 * 24 * time.Hour)
+	for i, grade := range []Grade{Hard, Good, Easy} {
+		result := Result{Word: items[i], Grade: grade}
+		if err := SaveReviewAt(db, DefaultScheduler{}, result, later); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	hard := queryItemInterval(t, db, "hard")
+	good := queryItemInterval(t, db, "good")
+	easy := queryItemInterval(t, db, "easy")
+	if hard != day {
+		t.Fatal("expected hard answer to keep the interval:", hard)
+	}
+	if !(hard < good && good < easy) {
+		t.Fatal("expected hard < good < easy:", hard, good, easy)
+	}
+}
+
+func TestGradeStoredInHistory(t *testing.T) {
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := UpdateReview(db, "foo", false); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	result := Result{Word: "bar", Correct: true, Grade: Easy}
+	if err := SaveReviewAt(db, DefaultScheduler{}, result, time.Now()); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	query := `SELECT grade FROM history WHERE word = ?`
+	for word, expected := range map[string]Grade{"foo": Again, "bar": Easy} {
+		var grade Grade
+		if err := db.QueryRow(query, word).Scan(&grade); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		if grade != expected {
+			t.Fatal("expected grade to be stored in history:", word, grade)
+		}
+	}
+}
+
+func TestResultJSON(t *testing.T) {
+	// Boolean results should map to fixed grades.
+	t.Parallel()
+
+	cases := map[string]Grade{
+		`{"word": "foo", "correct": true}`:                   Good,
+		`{"word": "foo", "correct": false}`:                  Again,
+		`{"word": "foo", "correct": true, "grade": "hard"}`:  Hard,
+		`{"word": "foo", "correct": false, "grade": "easy"}`: Easy,
+	}
+	for input, expected := range cases {
+		var result Result
+		if err := json.Unmarshal([]byte(input), &result); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+		if grade := result.GetGrade(); grade != expected {
+			t.Fatal("expected different grade:", input, grade)
+		}
+	}
+
+	var result Result
+	if err := json.Unmarshal([]byte(`{"word": "foo", "grade": "meh"}`), &result); err == nil {
+		t.Fatal("expected invalid grade to cause an error")
+	}
+}
+
+func TestCheckGrade(t *testing.T) {
+	t.Parallel()
+
+	if err := CheckGrade(0, false); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := CheckGrade(Hard, true); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := CheckGrade(Easy, false); err == nil {
+		t.Fatal("expected err to be non-nil")
+	}
+	if err := CheckGrade(Again, true); err == nil {
+		t.Fatal("expected err to be non-nil")
+	}
+}
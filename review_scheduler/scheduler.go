@@ -0,0 +1,118 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Pluggable scheduling algorithms.
+package review_scheduler
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/polycloze/polycloze/wilson"
+)
+
+// Name of the scheduler used when none is specified.
+const DefaultSchedulerName = "default"
+
+// Review scheduling algorithm.
+// Implementations may keep their own tables in the review DB, but they have to
+// express their schedule through `Review.Interval`.
+type Scheduler interface {
+	// Computes next review schedule.
+	// review is nil if the item hasn't been reviewed before.
+	NextReview(tx *sql.Tx, review *Review, grade Grade, now time.Time) (Review, error)
+
+	// Updates scheduler statistics using the result of a review.
+	// Called before the new schedule gets computed.
+	UpdateStats(tx *sql.Tx, review *Review, grade Grade, now time.Time) error
+
+	// Tunes scheduler parameters.
+	// Called after the new schedule gets saved.
+	AutoTune(tx *sql.Tx) error
+}
+
+// Optional interface for schedulers that can estimate the probability of
+// recall.
+// Used to evaluate schedulers.
+type Predictor interface {
+	// Predicts the probability that the student recalls the item at the given
+	// time.
+	// review is nil if the item hasn't been reviewed before.
+	PredictRecall(tx *sql.Tx, review *Review, now time.Time) (float64, error)
+}
+
+// Scheduler that doubles intervals in the `interval` table, and auto-tunes
+// them using Wilson score intervals.
+type DefaultScheduler struct {
+	// Thresholds for auto-tuning intervals.
+	// Uses `wilson.DefaultThresholds` if nil.
+	Thresholds *wilson.Thresholds
+}
+
+func (DefaultScheduler) NextReview(tx *sql.Tx, review *Review, grade Grade, now time.Time) (Review, error) {
+	return nextReview(tx, review, grade, now)
+}
+
+func (DefaultScheduler) UpdateStats(tx *sql.Tx, review *Review, grade Grade, now time.Time) error {
+	if review != nil && now.Before(review.Due()) {
+		// Only update interval stats if the student didn't cram.
+		return nil
+	}
+	return updateIntervalStats(tx, review, grade.IsCorrect())
+}
+
+func (s DefaultScheduler) AutoTune(tx *sql.Tx) error {
+	if s.Thresholds == nil {
+		return autoTune(tx, wilson.DefaultThresholds)
+	}
+	return autoTune(tx, *s.Thresholds)
+}
+
+// Estimates recall using the success rate at the review's interval, with
+// Laplace smoothing.
+func (DefaultScheduler) PredictRecall(tx *sql.Tx, review *Review, now time.Time) (float64, error) {
+	return predictRecall(tx, review)
+}
+
+// Registered schedulers.
+var schedulers = map[string]Scheduler{
+	DefaultSchedulerName: DefaultScheduler{},
+}
+
+// Registers scheduler under the given name.
+// Panics if the name is empty or already taken.
+// Should be called during initialization (e.g. in `init`).
+func Register(name string, s Scheduler) {
+	if name == "" {
+		panic("scheduler name can't be empty")
+	}
+	if _, ok := schedulers[name]; ok {
+		panic(fmt.Sprintf("scheduler already registered: %v", name))
+	}
+	schedulers[name] = s
+}
+
+// Returns the scheduler registered under the given name.
+// Returns the default scheduler if name is empty.
+func Lookup(name string) (Scheduler, error) {
+	if name == "" {
+		name = DefaultSchedulerName
+	}
+	s, ok := schedulers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown scheduler: %v", name)
+	}
+	return s, nil
+}
+
+// Returns names of registered schedulers in sorted order.
+func Names() []string {
+	var names []string
+	for name := range schedulers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package review_scheduler
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/utils"
+)
+
+// Schedules every review one week later and counts method calls.
+type weeklyScheduler struct {
+	stats int
+	tunes int
+}
+
+func (s *weeklyScheduler) NextReview(tx *sql.Tx, review *Review, grade Grade, now time.Time) (Review, error) {
+	return Review{Reviewed: now, Interval: 7 * day}, nil
+}
+
+func (s *weeklyScheduler) UpdateStats(tx *sql.Tx, review *Review, grade Grade, now time.Time) error {
+	s.stats++
+	return nil
+}
+
+func (s *weeklyScheduler) AutoTune(tx *sql.Tx) error {
+	s.tunes++
+	return nil
+}
+
+func TestLookupDefault(t *testing.T) {
+	// Empty name should return the default scheduler.
+	t.Parallel()
+
+	s, err := Lookup("")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, ok := s.(DefaultScheduler); !ok {
+		t.Fatal("expected default scheduler:", s)
+	}
+}
+
+func TestLookupUnknown(t *testing.T) {
+	t.Parallel()
+
+	if _, err := Lookup("does-not-exist"); err == nil {
+		t.Fatal("expected lookup of unregistered scheduler to fail")
+	}
+}
+
+func TestNamesContainsDefault(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range Names() {
+		if name == DefaultSchedulerName {
+			return
+		}
+	}
+	t.Fatal("expected names to contain default scheduler:", Names())
+}
+
+func TestBulkSaveReviewsUsesScheduler(t *testing.T) {
+	// Intervals should come from the given scheduler.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	s := &weeklyScheduler{}
+	reviews := []Result{
+		{Word: "foo", Correct: true},
+		{Word: "bar", Correct: false},
+	}
+	if err := BulkSaveReviews(db, s, reviews, time.Now()); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if s.stats != 2 || s.tunes != 2 {
+		t.Fatal("expected scheduler methods to be called once per review:", s.stats, s.tunes)
+	}
+
+	var count int
+	query := `SELECT count(*) FROM review WHERE interval = ?`
+	if err := db.QueryRow(query, int64((7 * day).Hours())).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 2 {
+		t.Fatal("expected all reviews to be scheduled a week later:", count)
+	}
+}
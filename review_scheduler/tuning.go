@@ -6,6 +6,7 @@ package review_scheduler
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
@@ -15,7 +16,7 @@ import (
 const day time.Duration = 24 * time.Hour
 
 // Auto-tunes intervals.
-func autoTune(tx *sql.Tx) error {
+func autoTune(tx *sql.Tx, t wilson.Thresholds) error {
 	query := `SELECT interval, correct, incorrect FROM interval ORDER BY interval ASC`
 	rows, err := tx.Query(query)
 	if err != nil {
@@ -36,11 +37,11 @@ func autoTune(tx *sql.Tx) error {
 			continue
 		}
 
-		if wilson.IsTooHard(correct, incorrect) {
+		if t.IsTooHard(correct, incorrect) {
 			if err := shortenInterval(tx, interval); err != nil {
 				return err
 			}
-		} else if wilson.IsTooEasy(correct, incorrect) {
+		} else if t.IsTooEasy(correct, incorrect) {
 			if err := lengthenInterval(tx, interval); err != nil {
 				return err
 			}
@@ -188,3 +189,19 @@ func updateIntervalStats(tx *sql.Tx, review *Review, correct bool) error {
 	_, err := tx.Exec(query, int64(interval.Hours()))
 	return err
 }
+
+// Estimates probability of recall from interval stats.
+func predictRecall(tx *sql.Tx, review *Review) (float64, error) {
+	var interval time.Duration = 0
+	if review != nil {
+		interval = review.Interval
+	}
+
+	var correct, incorrect int
+	query := `select correct, incorrect from interval where interval = ?`
+	err := tx.QueryRow(query, int64(interval.Hours())).Scan(&correct, &incorrect)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return 0, err
+	}
+	return float64(correct+1) / float64(correct+incorrect+2), nil
+}
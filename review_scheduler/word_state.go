@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// User-assigned word states.
+package review_scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+// States in the `word_state` table.
+const (
+	Suspended = "suspended" // Never scheduled until unsuspended
+	Drilling  = "drilling"  // Leech that the student chose to keep reviewing
+	Buried    = "buried"    // Hidden until `buried_until`
+	Known     = "known"     // Marked as known by the student
+)
+
+// Default interval of words marked as known.
+const DefaultKnownInterval = 180 * 24 * time.Hour
+
+// Sets state of item.
+// Pass an empty state to clear it.
+// Use BuryWord and MarkKnown for the other states.
+func SetWordState[T database.Querier](q T, item, state string) error {
+	var err error
+	switch state {
+	case "":
+		_, err = q.Exec(`DELETE FROM word_state WHERE item = ?`, item)
+	case Suspended, Drilling:
+		query := `
+			INSERT INTO word_state (item, state) VALUES (?, ?)
+			ON CONFLICT (item) DO UPDATE SET
+				state = excluded.state,
+				buried_until = NULL,
+				known_until = NULL,
+				updated = unixepoch('now')
+		`
+		_, err = q.Exec(query, item, state)
+	default:
+		err = fmt.Errorf("unknown state: %v", state)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set word state: %w", err)
+	}
+	return nil
+}
+
+// Clears item's state if it's the given state.
+// Other states are left alone, e.g. unburying doesn't unsuspend the item.
+func ClearWordState[T database.Querier](q T, item, state string) error {
+	query := `DELETE FROM word_state WHERE item = ? AND state = ?`
+	if _, err := q.Exec(query, item, state); err != nil {
+		return fmt.Errorf("failed to clear word state: %w", err)
+	}
+	return nil
+}
+
+// Hides item until the given time.
+// Works on new words too.
+func BuryWord[T database.Querier](q T, item string, until time.Time) error {
+	query := `
+		INSERT INTO word_state (item, state, buried_until) VALUES (?, ?, ?)
+		ON CONFLICT (item) DO UPDATE SET
+			state = excluded.state,
+			buried_until = excluded.buried_until,
+			known_until = NULL,
+			updated = unixepoch('now')
+	`
+	if _, err := q.Exec(query, item, Buried, until.Unix()); err != nil {
+		return fmt.Errorf("failed to bury word: %w", err)
+	}
+	return nil
+}
+
+// Marks item as known, so that it's hidden until the interval is over.
+// Works on new words too.
+// Doesn't add a review, because the item wasn't actually reviewed.
+func MarkKnown[T database.Querier](q T, item string, interval time.Duration, now time.Time) error {
+	if interval <= 0 {
+		interval = DefaultKnownInterval
+	}
+
+	query := `
+		INSERT INTO word_state (item, state, known_until) VALUES (?, ?, ?)
+		ON CONFLICT (item) DO UPDATE SET
+			state = excluded.state,
+			buried_until = NULL,
+			known_until = excluded.known_until,
+			updated = unixepoch('now')
+	`
+	if _, err := q.Exec(query, item, Known, now.Add(interval).Unix()); err != nil {
+		return fmt.Errorf("failed to mark word as known: %w", err)
+	}
+	return nil
+}
+
+// Deletes item's review data, so that it gets reintroduced as a new word.
+// Also deletes the item's review history.
+func ResetWord[T database.Querier](q T, item string) error {
+	tx, err := q.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to reset word: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	queries := []string{
+		`DELETE FROM review WHERE item = ?`,
+		`DELETE FROM card_review WHERE item = ?`,
+		`DELETE FROM word_state WHERE item = ?`,
+	}
+	for _, query := range queries {
+		if _, err := tx.Exec(query, item); err != nil {
+			return fmt.Errorf("failed to reset word: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to reset word: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package review_scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/utils"
+)
+
+func TestBuryWord(t *testing.T) {
+	// Buried words should come back after they're unburied.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := UpdateReview(db, "foo", false); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	if err := BuryWord(db, "foo", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	items, err := ScheduleReviewNow(db, 100)
+	if err != nil || len(items) != 0 {
+		t.Fatal("expected buried word to not be scheduled:", items, err)
+	}
+
+	if err := BuryWord(db, "foo", time.Now().Add(-time.Hour)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	items, err = ScheduleReviewNow(db, 100)
+	if err != nil || len(items) != 1 {
+		t.Fatal("expected word to be scheduled after burial:", items, err)
+	}
+}
+
+func TestClearWordState(t *testing.T) {
+	// Only the given state should be cleared.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := SetWordState(db, "foo", Suspended); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := BuryWord(db, "bar", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	// Wrong states.
+	if err := ClearWordState(db, "foo", Buried); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := ClearWordState(db, "bar", Suspended); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM word_state`).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 2 {
+		t.Fatal("expected other states to be left alone:", count)
+	}
+
+	if err := ClearWordState(db, "foo", Suspended); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := ClearWordState(db, "bar", Buried); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM word_state`).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 0 {
+		t.Fatal("expected states to be cleared:", count)
+	}
+}
+
+func TestMarkKnown(t *testing.T) {
+	// Known words should be hidden until the interval is over, without adding
+	// reviews to the history.
+	t.Parallel()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	if err := UpdateReview(db, "foo", false); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	now := time.Now()
+	for _, item := range []string{"foo", "bar"} {
+		if err := MarkKnown(db, item, 0, now); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	items, err := ScheduleReviewNow(db, 100)
+	if err != nil || len(items) != 0 {
+		t.Fatal("expected known word to not be scheduled:", items, err)
+	}
+
+	var reviews, history int
+	if err := db.QueryRow(`SELECT count(*) FROM review`).Scan(&reviews); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM history`).Scan(&history); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if reviews != 1 || history != 1 {
+		t.Fatal("expected known words to not add reviews:", reviews, history)
+	}
+
+	query := `UPDATE word_state SET known_until = ?`
+	if _, err := db.Exec(query, now.Add(-time.Hour).Unix()); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	items, err = ScheduleReviewNow(db, 100)
+	if err != nil || len(items) != 1 {
+		t.Fatal("expected word to be scheduled after the interval:", items, err)
+	}
+}
@@ -0,0 +1,124 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Sentences for lemmas (groups of inflected forms).
+package sentences
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+// Words that belong to the lemma.
+// Words that aren't in the `lemma` table are their own lemma.
+const lemmaFamily = `
+	WITH family (id, word) AS (
+		SELECT word.id, word.word FROM lemma
+		JOIN word ON (lemma.word = word.id)
+		WHERE lemma.lemma = @lemma
+		UNION
+		SELECT id, word FROM word
+		WHERE word = @lemma AND id NOT IN (SELECT word FROM lemma)
+	)
+`
+
+// Checks if the course DB has a `lemma` table.
+// Older course DBs don't have one.
+func HasLemmas[T database.Querier](q T) bool {
+	query := `SELECT count(*) FROM pragma_table_list WHERE name = 'lemma'`
+	var count int
+	_ = q.QueryRow(query).Scan(&count)
+	return count > 0
+}
+
+// Returns the lemma of the word.
+// Words that aren't in the `lemma` table are their own lemma.
+func LemmaOf[T database.Querier](q T, word string) (string, error) {
+	query := `
+		SELECT lemma.lemma FROM lemma
+		JOIN word ON (lemma.word = word.id)
+		WHERE word.word = ?
+		LIMIT 1
+	`
+	var lemma string
+	err := q.QueryRow(query, word).Scan(&lemma)
+	if errors.Is(err, sql.ErrNoRows) {
+		return word, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get lemma (%v): %w", word, err)
+	}
+	return lemma, nil
+}
+
+// Returns inflected forms of the lemma in the course.
+// The result is empty if the lemma isn't in the course.
+func LemmaForms[T database.Querier](q T, lemma string) ([]string, error) {
+	query := lemmaFamily + `SELECT word FROM family ORDER BY id`
+	rows, err := q.Query(query, sql.Named("lemma", lemma))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lemma forms (%v): %w", lemma, err)
+	}
+	defer rows.Close()
+
+	var forms []string
+	for rows.Next() {
+		var form string
+		if err := rows.Scan(&form); err != nil {
+			return nil, fmt.Errorf("failed to get lemma forms (%v): %w", lemma, err)
+		}
+		forms = append(forms, form)
+	}
+	return forms, nil
+}
+
+// Picks a sentence that contains any inflected form of the item's lemma.
+// The item may also be an inflected form (e.g. a word that was reviewed before
+// lemma mode was turned on).
+// Also returns the forms of the lemma, so the caller can find them in the
+// sentence.
+// Custom sentences are included if the custom sentence DB is attached.
+func PickLemmaSentence[T database.Querier](q T, item string) (Sentence, []string, error) {
+	lemma, err := LemmaOf(q, item)
+	if err != nil {
+		return Sentence{}, nil, err
+	}
+
+	forms, err := LemmaForms(q, lemma)
+	if err != nil {
+		return Sentence{}, nil, err
+	}
+	if len(forms) == 0 {
+		// The lemma may only appear in custom sentences.
+		forms = []string{lemma}
+	}
+
+	query := lemmaFamily + `
+		SELECT id, tatoeba_id, text, tokens, false FROM contains
+		JOIN sentence ON (sentence = id)
+		WHERE word IN (SELECT id FROM family)
+		ORDER BY random() LIMIT 1
+	`
+	if hasCustom(q) {
+		query = lemmaFamily + `
+			SELECT * FROM (
+				SELECT id, tatoeba_id, text, tokens, false FROM contains
+				JOIN sentence ON (sentence = id)
+				WHERE word IN (SELECT id FROM family)
+				UNION ALL
+				SELECT id, NULL, text, tokens, true FROM custom.contains
+				JOIN custom.sentence ON (sentence = id)
+				WHERE word = @lemma OR word IN (SELECT word FROM family)
+			)
+			ORDER BY random() LIMIT 1
+		`
+	}
+
+	var custom bool
+	sentence, err := scanSentence(q.QueryRow(query, sql.Named("lemma", lemma)), &custom)
+	sentence.Custom = custom
+	return sentence, forms, err
+}
@@ -7,6 +7,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/polycloze/polycloze/database"
 )
@@ -18,6 +19,36 @@ type Sentence struct {
 	TatoebaID int64    `json:"tatoebaID"`
 	Text      string   `json:"text"`
 	Tokens    []string `json:"tokens,omitempty"`
+
+	// True if the sentence is from the user's custom sentence DB.
+	// ID refers to a row in the custom sentence DB if so.
+	Custom bool `json:"custom,omitempty"`
+}
+
+// Checks if custom sentence DB is attached.
+func hasCustom[T database.Querier](q T) bool {
+	query := `SELECT count(*) FROM pragma_database_list WHERE name = 'custom'`
+	var count int
+	_ = q.QueryRow(query).Scan(&count)
+	return count > 0
+}
+
+// Returns text of the sentence.
+// Looks in the custom sentence DB if custom is true.
+func GetText[T database.Querier](q T, id int, custom bool) (string, error) {
+	query := `SELECT text FROM sentence WHERE id = ?`
+	if custom {
+		if !hasCustom(q) {
+			return "", fmt.Errorf("failed to get sentence text (%v): %w", id, sql.ErrNoRows)
+		}
+		query = `SELECT text FROM custom.sentence WHERE id = ?`
+	}
+
+	var text string
+	if err := q.QueryRow(query, id).Scan(&text); err != nil {
+		return "", fmt.Errorf("failed to get sentence text (%v): %w", id, err)
+	}
+	return text, nil
 }
 
 func findWordID[T database.Querier](q T, word string) (int, error) {
@@ -29,7 +60,38 @@ func findWordID[T database.Querier](q T, word string) (int, error) {
 	return id, err
 }
 
+// Scans sentence with tokens from a row.
+// The row may have an extra column that says if the sentence is custom.
+func scanSentence(row *sql.Row, custom ...*bool) (Sentence, error) {
+	var sentence Sentence
+	var tatoebaID sql.NullInt64
+	var tokens string
+
+	dest := []any{&sentence.ID, &tatoebaID, &sentence.Text, &tokens}
+	for _, c := range custom {
+		dest = append(dest, c)
+	}
+	if err := row.Scan(dest...); err != nil {
+		return sentence, err
+	}
+
+	if err := json.Unmarshal([]byte(tokens), &sentence.Tokens); err != nil {
+		return sentence, err
+	}
+
+	if tatoebaID.Valid {
+		sentence.TatoebaID = tatoebaID.Int64
+	} else {
+		sentence.TatoebaID = -1
+	}
+	return sentence, nil
+}
+
 func PickSentence[T database.Querier](q T, word string) (Sentence, error) {
+	if hasCustom(q) {
+		return pickSentenceWithCustom(q, word)
+	}
+
 	id, err := findWordID(q, word)
 	if err != nil {
 		return Sentence{}, err
@@ -45,27 +107,71 @@ func PickSentence[T database.Querier](q T, word string) (Sentence, error) {
 		WHERE word = ?
 		ORDER BY random() LIMIT 1
 	`
-	row := q.QueryRow(query, id)
+	return scanSentence(q.QueryRow(query, id))
+}
+
+// Like PickSentence, but also picks from the user's custom sentences.
+// Custom sentences may contain words that aren't in the course.
+func pickSentenceWithCustom[T database.Querier](q T, word string) (Sentence, error) {
+	id, err := findWordID(q, word)
+	if err != nil {
+		id = -1
+	}
 
+	query := `
+		SELECT * FROM (
+			SELECT id, tatoeba_id, text, tokens, false FROM contains
+			JOIN sentence ON (sentence = id)
+			WHERE word = ?
+			UNION ALL
+			SELECT id, NULL, text, tokens, true FROM custom.contains
+			JOIN custom.sentence ON (sentence = id)
+			WHERE word = ?
+		)
+		ORDER BY random() LIMIT 1
+	`
 	var sentence Sentence
-	var tatoebaID sql.NullInt64
-	var tokens string
+	var custom bool
+	sentence, err = scanSentence(q.QueryRow(query, id, word), &custom)
+	sentence.Custom = custom
+	return sentence, err
+}
 
-	err = row.Scan(&sentence.ID, &tatoebaID, &sentence.Text, &tokens)
+// Picks a sentence that contains the word, preferring sentences that also
+// contain many of the other words.
+// Words that aren't in the course are ignored.
+func PickSentenceCovering[T database.Querier](
+	q T,
+	word string,
+	others []string,
+) (Sentence, error) {
+	id, err := findWordID(q, word)
 	if err != nil {
-		return sentence, err
+		return Sentence{}, err
 	}
 
-	if err := json.Unmarshal([]byte(tokens), &sentence.Tokens); err != nil {
-		return sentence, err
+	ids := []any{id}
+	for _, other := range others {
+		if otherID, err := findWordID(q, other); err == nil {
+			ids = append(ids, otherID)
+		}
 	}
-
-	if tatoebaID.Valid {
-		sentence.TatoebaID = tatoebaID.Int64
-	} else {
-		sentence.TatoebaID = -1
+	if len(ids) == 1 {
+		return PickSentence(q, word)
 	}
-	return sentence, nil
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(ids)), ", ")
+	query := fmt.Sprintf(`
+		SELECT id, tatoeba_id, text, tokens FROM (
+			SELECT sentence, count(*) AS covered FROM contains
+			WHERE word IN (%v)
+				AND sentence IN (SELECT sentence FROM contains WHERE word = ?)
+			GROUP BY sentence
+		)
+		JOIN sentence ON (sentence = id)
+		ORDER BY covered DESC, random() LIMIT 1
+	`, placeholders)
+	return scanSentence(q.QueryRow(query, append(ids, id)...))
 }
 
 // Returns random sentence from the database.
@@ -5,10 +5,14 @@ package sentences
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
 	"testing"
 
 	"github.com/polycloze/polycloze/basedir"
 	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/utils"
 )
 
 func BenchmarkPickSentence(b *testing.B) {
@@ -36,3 +40,74 @@ func BenchmarkPickSentence(b *testing.B) {
 		b.Log("result:", sentence)
 	}
 }
+
+func TestPickCustomSentence(t *testing.T) {
+	// Should pick custom sentences even if the word isn't in the course.
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "custom.db")
+	custom, err := database.OpenCustomDB(path)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	query := `INSERT INTO sentence (id, text, tokens, translation) VALUES (1, 'Hallo Welt!', '["Hallo"," ","Welt","!"]', 'Hello world!')`
+	if _, err := custom.Exec(query); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := custom.Exec(`INSERT INTO contains (sentence, word) VALUES (1, 'hallo'), (1, 'welt')`); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	custom.Close()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	con, err := database.NewConnection(db, context.Background(), database.AttachCustom(path))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	sentence, err := PickSentence(con, "welt")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if !sentence.Custom || sentence.ID != 1 || sentence.TatoebaID != -1 || len(sentence.Tokens) != 4 {
+		t.Fatal("expected custom sentence:", sentence)
+	}
+}
+
+func TestGetCustomText(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "custom.db")
+	custom, err := database.OpenCustomDB(path)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	query := `INSERT INTO sentence (id, text, tokens, translation) VALUES (1, 'Hallo Welt!', '["Hallo"," ","Welt","!"]', 'Hello world!')`
+	if _, err := custom.Exec(query); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	custom.Close()
+
+	db := utils.TestingDatabase()
+	defer db.Close()
+
+	con, err := database.NewConnection(db, context.Background(), database.AttachCustom(path))
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	defer con.Close()
+
+	text, err := GetText(con, 1, true)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if text != "Hallo Welt!" {
+		t.Fatal("expected custom sentence text:", text)
+	}
+	if _, err := GetText(con, 2, true); !errors.Is(err, sql.ErrNoRows) {
+		t.Fatal("expected sql.ErrNoRows:", err)
+	}
+}
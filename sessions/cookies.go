@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // Name of cookie that stores session ID.
@@ -26,9 +27,9 @@ func validateCookie(db *sql.DB, c *http.Cookie) error {
 		return errors.New("incorrect cookie name")
 	}
 	var id string
-	// TODO scan expiry date for checking
-	query := `SELECT session_id FROM user_session WHERE session_id = ?`
-	if err := db.QueryRow(query, c.Value).Scan(&id); err != nil {
+	query := `SELECT session_id FROM user_session WHERE session_id = @id AND NOT ` + expired
+	args := []any{sql.Named("id", c.Value), sql.Named("now", time.Now().Unix())}
+	if err := db.QueryRow(query, args...).Scan(&id); err != nil {
 		return fmt.Errorf("invalid session ID: %w", err)
 	}
 	return nil
@@ -41,6 +42,7 @@ func setCookie(w http.ResponseWriter, id string) {
 		SameSite: http.SameSiteStrictMode,
 		HttpOnly: true,
 		Secure:   true,
+		MaxAge:   int(MaxAge.Seconds()),
 	}
 	http.SetCookie(w, &c)
 }
@@ -24,3 +24,22 @@ func CSRFToken(sessionID string) string {
 func CheckCSRFToken(sessionID, token string) bool {
 	return CSRFToken(sessionID) == token
 }
+
+// Validates CSRF token for the session.
+// Always fails for stateless sessions, so that form endpoints can only be used
+// from the browser.
+func (s *Session) CheckCSRFToken(token string) bool {
+	if s.IsStateless() {
+		return false
+	}
+	return CheckCSRFToken(s.ID, token)
+}
+
+// Validates CSRF token for JSON API requests.
+// Stateless sessions don't need CSRF tokens, because they don't use cookies.
+func (s *Session) CheckAPICSRFToken(token string) bool {
+	if s.IsStateless() {
+		return true
+	}
+	return CheckCSRFToken(s.ID, token)
+}
@@ -36,3 +36,15 @@ func TestCSRFTokenIdNotEqual(t *testing.T) {
 		t.Fatal("expected token and session ID to be different:", id, token)
 	}
 }
+
+func TestStatelessSessionCSRFToken(t *testing.T) {
+	t.Parallel()
+
+	s := NewStatelessSession(nil, 1, "foo")
+	if s.CheckCSRFToken(CSRFToken(s.ID)) {
+		t.Fatal("expected form endpoints to reject stateless sessions")
+	}
+	if !s.CheckAPICSRFToken("") {
+		t.Fatal("expected JSON API to accept stateless sessions")
+	}
+}
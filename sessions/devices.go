@@ -0,0 +1,69 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Active sessions of a user, for signing out other devices.
+package sessions
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Active session of a user.
+type SessionInfo struct {
+	// Row ID of the session.
+	// The session ID isn't exposed, because it's as good as a password.
+	ID int64
+
+	Created   time.Time
+	LastSeen  time.Time
+	UserAgent string
+
+	// True if this is the session that made the request.
+	Current bool
+}
+
+// Lists user's active sessions, most recently used first.
+// currentID: ID of the session that made the request.
+func ListSessions(db *sql.DB, userID int, currentID string) ([]SessionInfo, error) {
+	query := `
+		SELECT rowid, session_id, created, updated, user_agent FROM user_session
+		WHERE user_id = @userID AND NOT ` + expired + `
+		ORDER BY updated DESC
+	`
+	rows, err := db.Query(query, sql.Named("userID", userID), sql.Named("now", time.Now().Unix()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var result []SessionInfo
+	for rows.Next() {
+		var info SessionInfo
+		var sessionID string
+		var created, updated int64
+		if err := rows.Scan(&info.ID, &sessionID, &created, &updated, &info.UserAgent); err != nil {
+			return nil, fmt.Errorf("failed to list sessions: %w", err)
+		}
+		info.Created = time.Unix(created, 0)
+		info.LastSeen = time.Unix(updated, 0)
+		info.Current = sessionID == currentID
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// Signs out the user's session with the given row ID.
+func RevokeSession(db *sql.DB, userID int, id int64) error {
+	query := `DELETE FROM user_session WHERE rowid = ? AND user_id = ?`
+	result, err := db.Exec(query, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errors.New("failed to revoke session: session not found")
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Session expiry.
+package sessions
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Lifetimes of sessions of signed-in users.
+const (
+	MaxAge  = 30 * 24 * time.Hour // Since the session was created
+	MaxIdle = 7 * 24 * time.Hour  // Since the session was last used
+)
+
+// Lifetimes of sessions of users that aren't signed in.
+const (
+	maxAnonymousAge  = 4 * time.Hour
+	maxAnonymousIdle = 30 * time.Minute
+)
+
+// Minimum time between updates of a session's last-seen timestamp.
+const touchInterval = time.Minute
+
+// SQL condition that's true for expired rows in `user_session`.
+// Takes the current unix time as the named parameter @now.
+var expired = fmt.Sprintf(`(
+	created < @now - %d OR updated < @now - %d
+	OR (user_id IS NULL AND (created < @now - %d OR updated < @now - %d))
+)`,
+	int64(MaxAge.Seconds()),
+	int64(MaxIdle.Seconds()),
+	int64(maxAnonymousAge.Seconds()),
+	int64(maxAnonymousIdle.Seconds()),
+)
+
+// Deletes expired sessions and their messages.
+// Returns the number of deleted sessions.
+func PurgeExpired(db *sql.DB, now time.Time) (int64, error) {
+	query := `DELETE FROM user_session WHERE ` + expired
+	result, err := db.Exec(query, sql.Named("now", now.Unix()))
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge expired sessions: %w", err)
+	}
+	n, _ := result.RowsAffected()
+	return n, nil
+}
+
+// Updates the session's last-seen timestamp and user agent.
+// Skips the write if the session was updated recently by the same user agent.
+func touchSession(db *sql.DB, id, userAgent string) error {
+	query := `
+		UPDATE user_session
+		SET updated = unixepoch('now'), user_agent = @userAgent
+		WHERE session_id = @id
+			AND (updated < unixepoch('now') - @interval OR user_agent != @userAgent)
+	`
+	_, err := db.Exec(
+		query,
+		sql.Named("id", id),
+		sql.Named("userAgent", userAgent),
+		sql.Named("interval", int64(touchInterval.Seconds())),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// Purges expired sessions periodically in the background.
+// Returns a function that stops the janitor and waits for it to finish.
+func StartJanitor(db *sql.DB, interval time.Duration) func() {
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := PurgeExpired(db, time.Now()); err != nil {
+				log.Println(err)
+			}
+
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		wg.Wait()
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package sessions
+
+import (
+	"database/sql"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Inserts session with the given timestamps.
+// Use userID 0 for anonymous sessions.
+func insertSession(db *sql.DB, id string, userID int, created, updated time.Time) {
+	query := `
+		INSERT INTO user_session (session_id, user_id, username, created, updated)
+		VALUES (?, nullif(?, 0), nullif(?, ''), ?, ?)
+	`
+	username := ""
+	if userID != 0 {
+		username = "foo"
+	}
+	if _, err := db.Exec(query, id, userID, username, created.Unix(), updated.Unix()); err != nil {
+		panic(err)
+	}
+}
+
+// Creates user and returns its ID.
+func insertUser(db *sql.DB) int {
+	query := `INSERT INTO user (username, password) VALUES ('foo', 'hash') RETURNING id`
+	var id int
+	if err := db.QueryRow(query).Scan(&id); err != nil {
+		panic(err)
+	}
+	return id
+}
+
+func countSessions(db *sql.DB) int {
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM user_session`).Scan(&count); err != nil {
+		panic(err)
+	}
+	return count
+}
+
+func TestPurgeExpired(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	now := time.Now()
+	userID := insertUser(db)
+	insertSession(db, "active", userID, now.Add(-24*time.Hour), now.Add(-time.Hour))
+	insertSession(db, "idle", userID, now.Add(-10*24*time.Hour), now.Add(-8*24*time.Hour))
+	insertSession(db, "old", userID, now.Add(-31*24*time.Hour), now)
+	insertSession(db, "anonymous", 0, now.Add(-time.Hour), now.Add(-time.Hour))
+
+	if _, err := db.Exec(`INSERT INTO message (session_id, message) VALUES ('idle', 'hi')`); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	n, err := PurgeExpired(db, now)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if n != 3 || countSessions(db) != 1 {
+		t.Fatal("expected only the active session to be kept:", n)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT count(*) FROM message`).Scan(&count); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if count != 0 {
+		t.Fatal("expected messages of purged sessions to be deleted")
+	}
+}
+
+func TestValidateExpiredCookie(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	now := time.Now()
+	userID := insertUser(db)
+	insertSession(db, "active", userID, now, now)
+	insertSession(db, "idle", userID, now.Add(-10*24*time.Hour), now.Add(-8*24*time.Hour))
+
+	if err := validateCookie(db, &http.Cookie{Name: cookieName, Value: "active"}); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := validateCookie(db, &http.Cookie{Name: cookieName, Value: "idle"}); err == nil {
+		t.Fatal("expected idle session to be invalid")
+	}
+}
+
+func TestListAndRevokeSessions(t *testing.T) {
+	t.Parallel()
+	db := testDB()
+	defer db.Close()
+
+	now := time.Now()
+	userID := insertUser(db)
+	insertSession(db, "laptop", userID, now, now)
+	insertSession(db, "phone", userID, now, now.Add(-time.Hour))
+	insertSession(db, "anonymous", 0, now, now)
+
+	if err := touchSession(db, "phone", "Mozilla/5.0 (Android)"); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	infos, err := ListSessions(db, userID, "laptop")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(infos) != 2 {
+		t.Fatal("expected two sessions:", infos)
+	}
+
+	var phone SessionInfo
+	for _, info := range infos {
+		if info.Current {
+			continue
+		}
+		phone = info
+	}
+	if phone.UserAgent != "Mozilla/5.0 (Android)" {
+		t.Fatal("expected user agent to be saved:", phone)
+	}
+
+	if err := RevokeSession(db, userID+1, phone.ID); err == nil {
+		t.Fatal("expected users to only revoke their own sessions")
+	}
+	if err := RevokeSession(db, userID, phone.ID); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if countSessions(db) != 2 {
+		t.Fatal("expected revoked session to be deleted")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"encoding/base64"
 	"fmt"
 	"strings"
+	"time"
 )
 
 // Generates a cryptographically secure random 128-bit string in base64.
@@ -50,13 +51,9 @@ func reserveID(db *sql.DB, id string) error {
 }
 
 // Deletes session ID from the database.
-// Also deletes old (created > 4 hours ago) and idle (updated > 30 minutes ago) sessions.
+// Also deletes expired sessions.
 func deleteID(db *sql.DB, id string) error {
-	query := `
-		DELETE FROM user_session WHERE session_id = ?
-			OR created < (unixepoch('now') - 14400)
-			OR updated < (unixepoch('now') - 1800)
-	`
-	_, err := db.Exec(query, id)
+	query := `DELETE FROM user_session WHERE session_id = @id OR ` + expired
+	_, err := db.Exec(query, sql.Named("id", id), sql.Named("now", time.Now().Unix()))
 	return err
 }
@@ -94,7 +94,12 @@ func deleteMessages(tx *sql.Tx, sessionID string, contexts ...string) error {
 // Only returns messages that belong in the specified contexts.
 // If there are no context args, returns messages with a null context
 // (those that were inserted with `Session.Message` without context args).
+// Stateless sessions don't have messages.
 func (s *Session) Messages(contexts ...string) ([]Message, error) {
+	if s.stateless {
+		return nil, nil
+	}
+
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get messages from the database: %w", err)
@@ -120,7 +125,12 @@ func (s *Session) Messages(contexts ...string) ([]Message, error) {
 // If there are no context args, the message is added in a null context.
 // See `Session.Messages` for info on how messages are retrieved from a
 // specific context.
+// Does nothing in stateless sessions.
 func (s *Session) Message(kind string, message string, contexts ...string) error {
+	if s.stateless {
+		return nil
+	}
+
 	switch kind {
 	case "success":
 		break
@@ -4,6 +4,7 @@
 package sessions
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/http"
@@ -15,6 +16,42 @@ type Session struct {
 	ID   string
 	Data map[string]any
 	db   *sql.DB // Reference to auth/session DB.
+
+	// Stateless sessions aren't stored in the DB and don't use cookies.
+	// Used for requests authenticated with access tokens.
+	stateless bool
+}
+
+type contextValueKey int
+
+// Keys for getting values from request context.
+const (
+	keySession contextValueKey = iota
+)
+
+// Creates a stateless session for a user that has already been authenticated
+// by other means (e.g. access tokens).
+func NewStatelessSession(db *sql.DB, userID int, username string) *Session {
+	return &Session{
+		Data: map[string]any{
+			"userID":   userID,
+			"username": username,
+		},
+		db:        db,
+		stateless: true,
+	}
+}
+
+// Checks if the session is stateless.
+func (s *Session) IsStateless() bool {
+	return s != nil && s.stateless
+}
+
+// Returns copy of request that resumes the given (stateless) session.
+// See `ResumeSession`.
+func WithSession(r *http.Request, s *Session) *http.Request {
+	ctx := context.WithValue(r.Context(), keySession, s)
+	return r.WithContext(ctx)
 }
 
 // Checks if session data contains a user ID.
@@ -66,6 +103,9 @@ func StartSession(db *sql.DB, w http.ResponseWriter, r *http.Request) (*Session,
 	}
 
 	setCookie(w, id)
+	if err := touchSession(db, id, r.UserAgent()); err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
 
 	s := Session{
 		ID:   id,
@@ -77,7 +117,13 @@ func StartSession(db *sql.DB, w http.ResponseWriter, r *http.Request) (*Session,
 
 // Resumes an existing (valid) session.
 // If there's none, returns an error.
+// Returns the session in the request context if there's one (see
+// `WithSession`).
 func ResumeSession(db *sql.DB, w http.ResponseWriter, r *http.Request) (*Session, error) {
+	if s, ok := r.Context().Value(keySession).(*Session); ok {
+		return s, nil
+	}
+
 	c, err := getCookie(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resume session: %w", err)
@@ -88,6 +134,11 @@ func ResumeSession(db *sql.DB, w http.ResponseWriter, r *http.Request) (*Session
 		return nil, fmt.Errorf("failed to resume session: %w", err)
 	}
 
+	// Extends idle timeout.
+	if err := touchSession(db, c.Value, r.UserAgent()); err != nil {
+		return nil, fmt.Errorf("failed to resume session: %w", err)
+	}
+
 	s := Session{
 		ID:   c.Value,
 		Data: getData(db, c.Value),
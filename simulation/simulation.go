@@ -0,0 +1,277 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Offline evaluation of review schedulers using review logs.
+package simulation
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/replay"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+	"github.com/polycloze/polycloze/text"
+)
+
+const day = 24 * time.Hour
+
+// Scheduler configuration to evaluate.
+type Config struct {
+	Name      string
+	Scheduler rs.Scheduler
+}
+
+// Retention stats of reviews whose scheduled interval is in the bucket.
+type Bucket struct {
+	Interval  string  `json:"interval"` // Label
+	Reviews   int     `json:"reviews"`
+	Predicted float64 `json:"predicted"` // Mean predicted recall
+	Actual    float64 `json:"actual"`    // Actual recall rate
+}
+
+// Number of reviews scheduled on a day (UTC).
+type Workload struct {
+	Day string `json:"day"` // YYYY-MM-DD
+	Due int    `json:"due"`
+}
+
+// Evaluation results of a scheduler configuration.
+type Report struct {
+	Name string `json:"name"`
+
+	// Number of reviews of previously seen words.
+	// First reviews aren't used for evaluation, since there's nothing to
+	// predict yet.
+	Reviews int `json:"reviews"`
+
+	PredictedRecall float64 `json:"predictedRecall"`
+	ActualRecall    float64 `json:"actualRecall"`
+	LogLoss         float64 `json:"logLoss"`
+
+	Buckets []Bucket `json:"buckets"`
+
+	Workload     []Workload `json:"workload"`
+	MeanWorkload float64    `json:"meanWorkload"`
+	MaxWorkload  int        `json:"maxWorkload"`
+}
+
+// Upper bounds of interval buckets.
+// Intervals = 0 get their own bucket.
+var bucketBounds = []time.Duration{
+	day, 2 * day, 4 * day, 8 * day, 16 * day, 32 * day, 64 * day, 128 * day, 256 * day,
+}
+
+func bucketLabels() []string {
+	labels := []string{"0"}
+	for _, bound := range bucketBounds {
+		labels = append(labels, fmt.Sprintf("<%vd", int(bound/day)))
+	}
+	last := bucketBounds[len(bucketBounds)-1]
+	return append(labels, fmt.Sprintf(">=%vd", int(last/day)))
+}
+
+func bucketIndex(interval time.Duration) int {
+	if interval <= 0 {
+		return 0
+	}
+	for i, bound := range bucketBounds {
+		if interval < bound {
+			return i + 1
+		}
+	}
+	return len(bucketBounds) + 1
+}
+
+// Predicts recall using the scheduler if it implements `rs.Predictor`.
+// Otherwise, assumes exponential forgetting with 90% recall at the due date,
+// and 50% recall for items that are due immediately.
+func predict(tx *sql.Tx, s rs.Scheduler, review *rs.Review, now time.Time) (float64, error) {
+	if p, ok := s.(rs.Predictor); ok {
+		return p.PredictRecall(tx, review, now)
+	}
+	if review.Interval <= 0 {
+		return 0.5, nil
+	}
+	elapsed := now.Sub(review.Reviewed)
+	return math.Pow(0.9, float64(elapsed)/float64(review.Interval)), nil
+}
+
+// Limits probability for computing log-loss.
+func clip(p float64) float64 {
+	const eps = 1e-9
+	return math.Min(math.Max(p, eps), 1-eps)
+}
+
+// Accumulates stats for a report.
+type accumulator struct {
+	reviews   int
+	predicted float64
+	correct   int
+	logLoss   float64
+
+	buckets []Bucket
+	due     map[string]int
+}
+
+func newAccumulator() *accumulator {
+	var buckets []Bucket
+	for _, label := range bucketLabels() {
+		buckets = append(buckets, Bucket{Interval: label})
+	}
+	return &accumulator{
+		buckets: buckets,
+		due:     make(map[string]int),
+	}
+}
+
+func (a *accumulator) addPrediction(interval time.Duration, p float64, correct bool) {
+	a.reviews++
+	a.predicted += p
+
+	bucket := &a.buckets[bucketIndex(interval)]
+	bucket.Reviews++
+	bucket.Predicted += p
+
+	if correct {
+		a.correct++
+		bucket.Actual++
+		a.logLoss -= math.Log(clip(p))
+	} else {
+		a.logLoss -= math.Log(1 - clip(p))
+	}
+}
+
+func (a *accumulator) addDue(due time.Time) {
+	a.due[due.UTC().Format("2006-01-02")]++
+}
+
+func (a *accumulator) report(name string) Report {
+	r := Report{Name: name, Reviews: a.reviews}
+	if a.reviews > 0 {
+		n := float64(a.reviews)
+		r.PredictedRecall = a.predicted / n
+		r.ActualRecall = float64(a.correct) / n
+		r.LogLoss = a.logLoss / n
+	}
+
+	for _, bucket := range a.buckets {
+		if bucket.Reviews > 0 {
+			n := float64(bucket.Reviews)
+			bucket.Predicted /= n
+			bucket.Actual /= n
+		}
+		r.Buckets = append(r.Buckets, bucket)
+	}
+
+	// Include days without due reviews between the first and last day.
+	var days []string
+	for day := range a.due {
+		days = append(days, day)
+	}
+	sort.Strings(days)
+	if len(days) == 0 {
+		return r
+	}
+
+	first, _ := time.Parse("2006-01-02", days[0])
+	last, _ := time.Parse("2006-01-02", days[len(days)-1])
+	total := 0
+	for t := first; !t.After(last); t = t.Add(day) {
+		key := t.Format("2006-01-02")
+		due := a.due[key]
+		r.Workload = append(r.Workload, Workload{Day: key, Due: due})
+		total += due
+		if due > r.MaxWorkload {
+			r.MaxWorkload = due
+		}
+	}
+	r.MeanWorkload = float64(total) / float64(len(r.Workload))
+	return r
+}
+
+// Predicts recall before saving the review, then records the new due date.
+func simulateReview(
+	db *sql.DB,
+	s rs.Scheduler,
+	result rs.Result,
+	now time.Time,
+	a *accumulator,
+) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	review, err := rs.MostRecentReview(tx, result.Word)
+	if err != nil {
+		return err
+	}
+	if review != nil {
+		p, err := predict(tx, s, review, now)
+		if err != nil {
+			return err
+		}
+		a.addPrediction(review.Interval, p, result.GetGrade().IsCorrect())
+	}
+
+	if err := rs.UpdateReviewAtTx(tx, s, result, now); err != nil {
+		return err
+	}
+
+	next, err := rs.MostRecentReview(tx, result.Word)
+	if err != nil {
+		return err
+	}
+	if next != nil {
+		a.addDue(next.Due())
+	}
+	return tx.Commit()
+}
+
+// Replays log into a new in-memory DB, and records predictions and workload.
+func simulateLog(s rs.Scheduler, events []replay.ReviewEvent, a *accumulator) error {
+	db, err := database.OpenReviewDB(":memory:")
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	// Every connection to ":memory:" gets its own DB.
+	db.SetMaxOpenConns(1)
+
+	events = append([]replay.ReviewEvent{}, events...)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Reviewed.Before(events[j].Reviewed)
+	})
+
+	for _, event := range events {
+		result := event.Result()
+		result.Word = text.Casefold(result.Word)
+
+		if err := simulateReview(db, s, result, event.Reviewed, a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evaluates scheduler configurations on a corpus of review logs.
+// Each log is replayed separately, as if they came from different students.
+func Simulate(configs []Config, logs [][]replay.ReviewEvent) ([]Report, error) {
+	var reports []Report
+	for _, config := range configs {
+		a := newAccumulator()
+		for _, events := range logs {
+			if err := simulateLog(config.Scheduler, events, a); err != nil {
+				return nil, fmt.Errorf("failed to simulate scheduler (%v): %w", config.Name, err)
+			}
+		}
+		reports = append(reports, a.report(config.Name))
+	}
+	return reports, nil
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package simulation
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/polycloze/polycloze/replay"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+)
+
+// Schedules every review one week later.
+// Doesn't implement `rs.Predictor`.
+type weeklyScheduler struct{}
+
+func (weeklyScheduler) NextReview(tx *sql.Tx, review *rs.Review, grade rs.Grade, now time.Time) (rs.Review, error) {
+	return rs.Review{Reviewed: now, Interval: 7 * day}, nil
+}
+
+func (weeklyScheduler) UpdateStats(tx *sql.Tx, review *rs.Review, grade rs.Grade, now time.Time) error {
+	return nil
+}
+
+func (weeklyScheduler) AutoTune(tx *sql.Tx) error {
+	return nil
+}
+
+func testLog() []replay.ReviewEvent {
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	return []replay.ReviewEvent{
+		{Word: "foo", Reviewed: start, Correct: true},
+		{Word: "bar", Reviewed: start, Correct: false},
+		{Word: "foo", Reviewed: start.Add(2 * day), Correct: true},
+		{Word: "bar", Reviewed: start.Add(3 * day), Correct: true},
+		{Word: "foo", Reviewed: start.Add(9 * day), Correct: false},
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	t.Parallel()
+
+	configs := []Config{
+		{Name: "default", Scheduler: rs.DefaultScheduler{}},
+		{Name: "weekly", Scheduler: weeklyScheduler{}},
+	}
+	logs := [][]replay.ReviewEvent{testLog(), testLog()}
+
+	reports, err := Simulate(configs, logs)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(reports) != 2 {
+		t.Fatal("expected one report per config:", reports)
+	}
+
+	for _, report := range reports {
+		// First reviews aren't predicted.
+		if report.Reviews != 6 {
+			t.Fatal("expected six predicted reviews:", report)
+		}
+		if report.ActualRecall != 4.0/6.0 {
+			t.Fatal("unexpected actual recall:", report.ActualRecall)
+		}
+		if report.LogLoss <= 0 {
+			t.Fatal("expected log-loss to be positive:", report.LogLoss)
+		}
+
+		total := 0
+		for _, bucket := range report.Buckets {
+			total += bucket.Reviews
+		}
+		if total != report.Reviews {
+			t.Fatal("expected every review to be in a bucket:", report.Buckets)
+		}
+
+		total = 0
+		for _, workload := range report.Workload {
+			total += workload.Due
+		}
+		if total != 10 {
+			t.Fatal("expected every review to be scheduled:", report.Workload)
+		}
+	}
+
+	// Every review in the weekly schedule is in the 4-8 day bucket.
+	if reports[1].Buckets[bucketIndex(7*day)].Reviews != 6 {
+		t.Fatal("expected reviews to be in the weekly bucket:", reports[1].Buckets)
+	}
+}
+
+func TestBucketIndex(t *testing.T) {
+	t.Parallel()
+
+	if i := bucketIndex(0); i != 0 {
+		t.Fatal("expected zero interval to have its own bucket:", i)
+	}
+	if i := bucketIndex(12 * time.Hour); i != 1 {
+		t.Fatal("expected interval to be in the first bucket:", i)
+	}
+	if i := bucketIndex(1000 * day); i != len(bucketLabels())-1 {
+		t.Fatal("expected interval to be in the last bucket:", i)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Normalization used to accept alternative spellings of answers.
+package text
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Removes combining marks (e.g. "é" -> "e").
+// Letters that aren't decomposable (e.g. "ø") are left as is.
+func StripDiacritics(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	result, _, err := transform.String(t, s)
+	if err != nil {
+		return s
+	}
+	return result
+}
+
+// Language-specific replacements for letters that are commonly spelled out
+// when they can't be typed (e.g. German "ä" -> "ae").
+// Keys are ISO 639-3 codes.
+var foldings = map[string]*strings.Replacer{
+	"dan": strings.NewReplacer(
+		"æ", "ae", "ø", "oe", "å", "aa",
+		"Æ", "Ae", "Ø", "Oe", "Å", "Aa",
+	),
+	"deu": strings.NewReplacer(
+		"ä", "ae", "ö", "oe", "ü", "ue",
+		"Ä", "Ae", "Ö", "Oe", "Ü", "Ue",
+	),
+	"nld": strings.NewReplacer("ĳ", "ij", "Ĳ", "IJ"),
+	"nob": strings.NewReplacer(
+		"æ", "ae", "ø", "oe", "å", "aa",
+		"Æ", "Ae", "Ø", "Oe", "Å", "Aa",
+	),
+	"swe": strings.NewReplacer(
+		"ä", "ae", "ö", "oe", "å", "aa",
+		"Ä", "Ae", "Ö", "Oe", "Å", "Aa",
+	),
+}
+
+// Applies language-specific folding.
+// Returns the text unchanged if there are no foldings for the language.
+func FoldLanguage(lang, s string) string {
+	replacer, ok := foldings[lang]
+	if !ok {
+		return s
+	}
+	return replacer.Replace(s)
+}
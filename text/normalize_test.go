@@ -0,0 +1,30 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package text
+
+import (
+	"testing"
+)
+
+func TestStripDiacritics(t *testing.T) {
+	t.Parallel()
+
+	if s := StripDiacritics("café crème"); s != "cafe creme" {
+		t.Fatal("expected diacritics to be removed:", s)
+	}
+	if s := StripDiacritics("søster"); s != "søster" {
+		t.Fatal("expected non-decomposable letters to be unchanged:", s)
+	}
+}
+
+func TestFoldLanguage(t *testing.T) {
+	t.Parallel()
+
+	if s := FoldLanguage("deu", "Ärger"); s != "Aerger" {
+		t.Fatal("expected umlaut to be spelled out:", s)
+	}
+	if s := FoldLanguage("spa", "año"); s != "año" {
+		t.Fatal("expected text to be unchanged:", s)
+	}
+}
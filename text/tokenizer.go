@@ -0,0 +1,81 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Sentence tokenizer.
+package text
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Punctuation that gets split off the start of words.
+const prefixes = "\"'([{<«‹“‘„‚¿¡"
+
+// Punctuation that gets split off the end of words.
+const suffixes = "\"')]}>»›”’.,;:!?…%"
+
+// Tokenizes sentence.
+// Approximates the default rules of the spaCy tokenizers used by
+// `python/scripts/tokenizer.py`: whitespace is kept as separate tokens, so
+// joining the tokens gives back the original sentence, and punctuation at the
+// start and end of words gets split off.
+// Runs of periods (e.g. "...") are kept together.
+func Tokenize(sentence string) []string {
+	var tokens []string
+	for len(sentence) > 0 {
+		r, _ := utf8.DecodeRuneInString(sentence)
+		end := strings.IndexFunc(sentence, func(c rune) bool {
+			return unicode.IsSpace(c) != unicode.IsSpace(r)
+		})
+		if end < 0 {
+			end = len(sentence)
+		}
+
+		chunk := sentence[:end]
+		sentence = sentence[end:]
+		if unicode.IsSpace(r) {
+			tokens = append(tokens, chunk)
+		} else {
+			tokens = append(tokens, splitPunctuation(chunk)...)
+		}
+	}
+	return tokens
+}
+
+// Splits prefix and suffix punctuation off a chunk of non-whitespace
+// characters.
+func splitPunctuation(chunk string) []string {
+	var before []string
+	for len(chunk) > 0 {
+		r, size := utf8.DecodeRuneInString(chunk)
+		if !strings.ContainsRune(prefixes, r) || size == len(chunk) {
+			break
+		}
+		before = append(before, chunk[:size])
+		chunk = chunk[size:]
+	}
+
+	var after []string
+	for len(chunk) > 0 {
+		r, size := utf8.DecodeLastRuneInString(chunk)
+		if !strings.ContainsRune(suffixes, r) || size == len(chunk) {
+			break
+		}
+
+		// Keep runs of periods together.
+		token := chunk[len(chunk)-size:]
+		if r == '.' {
+			token = chunk[len(strings.TrimRight(chunk, ".")):]
+			if len(token) == len(chunk) {
+				break
+			}
+		}
+		after = append([]string{token}, after...)
+		chunk = chunk[:len(chunk)-len(token)]
+	}
+
+	tokens := append(before, chunk)
+	return append(tokens, after...)
+}
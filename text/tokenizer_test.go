@@ -0,0 +1,31 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package text
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	t.Parallel()
+
+	examples := map[string][]string{
+		"Hello, world!":      {"Hello", ",", " ", "world", "!"},
+		"«Ça va?» dit-il...": {"«", "Ça", " ", "va", "?", "»", " ", "dit-il", "..."},
+		"¿Qué  pasa?":        {"¿", "Qué", "  ", "pasa", "?"},
+		"(Das ist \"gut\".)": {"(", "Das", " ", "ist", " ", "\"", "gut", "\"", ".", ")"},
+		"!":                  {"!"},
+	}
+	for sentence, expected := range examples {
+		tokens := Tokenize(sentence)
+		if !reflect.DeepEqual(tokens, expected) {
+			t.Fatalf("expected %q, got %q", expected, tokens)
+		}
+		if strings.Join(tokens, "") != sentence {
+			t.Fatal("expected tokens to join into the original sentence:", tokens)
+		}
+	}
+}
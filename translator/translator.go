@@ -19,6 +19,14 @@ type Translation struct {
 func Translate[T database.Querier](q T, sentence sentences.Sentence) (Translation, error) {
 	var translation Translation
 
+	if sentence.Custom {
+		query := `SELECT translation FROM custom.sentence WHERE id = ?`
+		if err := q.QueryRow(query, sentence.ID).Scan(&translation.Text); err != nil {
+			return translation, fmt.Errorf("failed to translate sentence: %w", err)
+		}
+		return translation, nil
+	}
+
 	if sentence.TatoebaID <= 0 {
 		return translation, errors.New("sentence has no TatoebaID")
 	}
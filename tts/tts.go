@@ -0,0 +1,243 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Speech synthesis using a local text-to-speech command.
+package tts
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Max time to wait for the TTS command.
+const timeout = 30 * time.Second
+
+// Prefix of audio files that are still being generated.
+const tempPrefix = ".tmp-"
+
+var (
+	ErrEmptyCommand    = errors.New("empty TTS command")
+	ErrTextPlaceholder = errors.New("TTS command can't have a {text} placeholder; text is written to stdin")
+)
+
+// Local TTS command.
+// Arguments may contain the following placeholders:
+//   - {lang}: BCP 47 code of the sentence language
+//   - {output}: path of the audio file to create
+//
+// The text is written to the command's stdin, so that it can't be mistaken for
+// a command-line option.
+type Command struct {
+	Args []string
+
+	// Extension of generated audio files (e.g. "wav").
+	Ext string
+
+	// Where generated audio files are stored.
+	CacheDir string
+
+	// Max number of commands to run at the same time. Unlimited if
+	// non-positive.
+	MaxConcurrent int
+
+	// Least recently used audio files are deleted when the cache gets bigger
+	// than this (in bytes). Unlimited if non-positive.
+	MaxCacheSize int64
+
+	once sync.Once
+	sem  chan struct{}
+
+	// Serializes cache pruning.
+	mu sync.Mutex
+}
+
+// Parses TTS command.
+// Arguments are separated by whitespace, and can't be quoted.
+func ParseCommand(command, ext, cacheDir string) (*Command, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, ErrEmptyCommand
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "{text}") {
+			return nil, ErrTextPlaceholder
+		}
+	}
+	if ext == "" {
+		ext = "wav"
+	}
+	return &Command{
+		Args:     args,
+		Ext:      strings.TrimPrefix(ext, "."),
+		CacheDir: cacheDir,
+	}, nil
+}
+
+// Returns path of cached audio file for the text.
+func (c *Command) cachePath(lang, text string) string {
+	sum := sha256.Sum256([]byte(text))
+	name := hex.EncodeToString(sum[:]) + "." + c.Ext
+	return filepath.Join(c.CacheDir, filepath.Base(lang), name)
+}
+
+// Returns command arguments with placeholders replaced.
+func (c *Command) expand(lang, output string) []string {
+	replacer := strings.NewReplacer("{lang}", lang, "{output}", output)
+
+	var args []string
+	for _, arg := range c.Args {
+		args = append(args, replacer.Replace(arg))
+	}
+	return args
+}
+
+// Waits until fewer than MaxConcurrent commands are running.
+// The caller has to call release afterwards if there's no error.
+func (c *Command) acquire(ctx context.Context) error {
+	if c.MaxConcurrent <= 0 {
+		return nil
+	}
+	c.once.Do(func() {
+		c.sem = make(chan struct{}, c.MaxConcurrent)
+	})
+	select {
+	case c.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("gave up waiting for TTS command: %w", ctx.Err())
+	}
+}
+
+func (c *Command) release() {
+	if c.MaxConcurrent > 0 {
+		<-c.sem
+	}
+}
+
+type cachedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+}
+
+// Deletes least recently used audio files until the cache is within
+// MaxCacheSize.
+// Doesn't delete keep, or files that are still being generated.
+func (c *Command) prune(keep string) error {
+	if c.MaxCacheSize <= 0 {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var files []cachedFile
+	var total int64
+	err := filepath.WalkDir(c.CacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), tempPrefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			// The file may have been deleted in the meantime.
+			return nil
+		}
+		files = append(files, cachedFile{path, info.Size(), info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to prune TTS cache: %w", err)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].modTime.Before(files[j].modTime)
+	})
+	for _, file := range files {
+		if total <= c.MaxCacheSize {
+			break
+		}
+		if file.path == keep {
+			continue
+		}
+		if err := os.Remove(file.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to prune TTS cache: %w", err)
+		}
+		total -= file.size
+	}
+	return nil
+}
+
+// Returns true if the audio file exists.
+// Marks the file as recently used, so that it gets pruned last.
+func touch(name string) bool {
+	now := time.Now()
+	return os.Chtimes(name, now, now) == nil
+}
+
+// Synthesizes speech and returns the path to the audio file.
+// Audio files are cached, so the command only runs once per text.
+func (c *Command) Synthesize(ctx context.Context, lang, text string) (string, error) {
+	name := c.cachePath(lang, text)
+	if touch(name) {
+		return name, nil
+	}
+
+	if err := c.acquire(ctx); err != nil {
+		return "", err
+	}
+	defer c.release()
+
+	// Another request may have generated the file while this one was waiting.
+	if touch(name) {
+		return name, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(name), 0o700); err != nil {
+		return "", fmt.Errorf("failed to create TTS cache directory: %w", err)
+	}
+
+	// Write to a temporary file first, so that incomplete audio files don't get
+	// served.
+	temp, err := os.CreateTemp(filepath.Dir(name), tempPrefix+"*."+c.Ext)
+	if err != nil {
+		return "", fmt.Errorf("failed to create audio file: %w", err)
+	}
+	temp.Close()
+	defer os.Remove(temp.Name())
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	args := c.expand(lang, temp.Name())
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("TTS command failed: %w: %s", err, output)
+	}
+
+	if err := os.Rename(temp.Name(), name); err != nil {
+		return "", fmt.Errorf("failed to save audio file: %w", err)
+	}
+
+	// The audio file is usable even if pruning fails.
+	if err := c.prune(name); err != nil {
+		log.Println(err)
+	}
+	return name, nil
+}
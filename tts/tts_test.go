@@ -0,0 +1,134 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package tts
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func TestParseEmptyCommand(t *testing.T) {
+	t.Parallel()
+	if _, err := ParseCommand("  ", "", t.TempDir()); !errors.Is(err, ErrEmptyCommand) {
+		t.Fatal("expected ErrEmptyCommand:", err)
+	}
+}
+
+func TestParseTextPlaceholder(t *testing.T) {
+	// Text could be mistaken for an option if it were passed as an argument.
+	t.Parallel()
+	if _, err := ParseCommand("espeak-ng {text}", "", t.TempDir()); !errors.Is(err, ErrTextPlaceholder) {
+		t.Fatal("expected ErrTextPlaceholder:", err)
+	}
+}
+
+func TestSynthesize(t *testing.T) {
+	// Output should be cached.
+	t.Parallel()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	dir := t.TempDir()
+	c, err := ParseCommand(`sh -c cat>{output}`, "txt", dir)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	name, err := c.Synthesize(context.Background(), "de", "Hallo")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	bytes, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if string(bytes) != "Hallo" {
+		t.Fatal("expected text to be written to stdin:", string(bytes))
+	}
+
+	// Cached file should be reused even if the command changes.
+	c.Args = []string{"false"}
+	cached, err := c.Synthesize(context.Background(), "de", "Hallo")
+	if err != nil || cached != name {
+		t.Fatal("expected cached audio file:", cached, err)
+	}
+}
+
+func TestSynthesizeFailure(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false not found")
+	}
+
+	c, err := ParseCommand("false", "wav", t.TempDir())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := c.Synthesize(context.Background(), "de", "Hallo"); err == nil {
+		t.Fatal("expected error from failing command")
+	}
+}
+
+func TestSynthesizeTextLooksLikeOption(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	c, err := ParseCommand(`sh -c cat>{output}`, "txt", t.TempDir())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	name, err := c.Synthesize(context.Background(), "de", "--help")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	bytes, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if string(bytes) != "--help" {
+		t.Fatal("expected text to be written to stdin:", string(bytes))
+	}
+}
+
+func TestSynthesizePrunesCache(t *testing.T) {
+	// Least recently used files should be deleted when the cache is too big.
+	t.Parallel()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not found")
+	}
+
+	c, err := ParseCommand(`sh -c cat>{output}`, "txt", t.TempDir())
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	c.MaxConcurrent = 1
+	c.MaxCacheSize = 10
+
+	old, err := c.Synthesize(context.Background(), "de", "Hallo")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(old, past, past); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	name, err := c.Synthesize(context.Background(), "de", "Guten Tag")
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if _, err := os.Stat(name); err != nil {
+		t.Fatal("expected new audio file to be kept:", err)
+	}
+	if _, err := os.Stat(old); !errors.Is(err, os.ErrNotExist) {
+		t.Fatal("expected least recently used audio file to be deleted:", err)
+	}
+}
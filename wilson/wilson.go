@@ -45,24 +45,42 @@ func Wilson(success, fail int, z float64) float64 {
 	return (ns+z2/2)/(n+z2) + (z/(n+z2))*math.Sqrt((ns*nf)/n+z2/4)
 }
 
-func IsTooEasy(correct, incorrect int) bool {
-	// Threshold can't be too high or the tuner will be too conservative.
-	// Only uses 0.85 confidence, higher values require too many samples.
+// Parameters for deciding if a success rate is too high or too low.
+type Thresholds struct {
+	// Too easy if the one-sided lower bound computed with EasyZ is above Easy.
+	EasyZ float64
+	Easy  float64
 
-	z := -1.035 // z-score for one-sided confidence interval (85% confidence)
-	lower := Wilson(correct, incorrect, z)
+	// Too hard if the one-sided upper bound computed with HardZ is below Hard.
+	HardZ float64
+	Hard  float64
+}
 
-	// 85% likelihood that the true proportion is bounded below by `lower`.
-	// It's too hard to level up with a 0.9 test when incorrect > 0.
-	return lower > 0.85
+// Threshold can't be too high or the tuner will be too conservative.
+// Only uses 0.85 confidence for the lower bound, higher values require too
+// many samples.
+// It's too hard to level up with a 0.9 test when incorrect > 0.
+// 0.85 threshold is chosen so tuner won't trigger with < 5 samples.
+// The upper bound uses 99.9% confidence.
+var DefaultThresholds = Thresholds{
+	EasyZ: -1.035,
+	Easy:  0.85,
+	HardZ: 3.1,
+	Hard:  0.8,
+}
 
-	// 0.85 threshold is chosen so tuner won't trigger with < 5 samples.
+func (t Thresholds) IsTooEasy(correct, incorrect int) bool {
+	return Wilson(correct, incorrect, t.EasyZ) > t.Easy
 }
 
-func IsTooHard(correct, incorrect int) bool {
-	z := 3.1 // z-score for one-sided confidence interval
-	upper := Wilson(correct, incorrect, z)
+func (t Thresholds) IsTooHard(correct, incorrect int) bool {
+	return Wilson(correct, incorrect, t.HardZ) < t.Hard
+}
 
-	// 99.9% confident that the true proportion is bounded above by `upper`.
-	return upper < 0.8
+func IsTooEasy(correct, incorrect int) bool {
+	return DefaultThresholds.IsTooEasy(correct, incorrect)
+}
+
+func IsTooHard(correct, incorrect int) bool {
+	return DefaultThresholds.IsTooHard(correct, incorrect)
 }
@@ -0,0 +1,64 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package word_scheduler
+
+import (
+	"github.com/polycloze/polycloze/database"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+)
+
+// Gets up to n words that are new in the card kind's track.
+// Only words that the student has already learned in the cloze track are
+// included, so that words are recognised before they're produced.
+func getNewCardWordsWith[T database.Querier](
+	q T,
+	kind rs.Kind,
+	n int,
+	pred func(word string) bool,
+) ([]Word, error) {
+	query := `
+		SELECT item, coalesce(frequency_class, 0) FROM review
+		LEFT JOIN word ON (word.word = review.item)
+		WHERE correct AND item NOT IN (
+			SELECT item FROM card_review WHERE kind = ?
+		) AND item NOT IN (SELECT item FROM hidden_item)
+		ORDER BY learned ASC
+	`
+	rows, err := q.Query(query, kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return getNRows(rows, n, pred)
+}
+
+// Like GetWordsWith, but schedules words in the card kind's track.
+func GetCardWordsWith[T database.Querier](
+	q T,
+	kind rs.Kind,
+	n int,
+	pred func(word string) bool,
+) ([]Word, error) {
+	if kind.IsCloze() {
+		return GetWordsWith(q, n, pred)
+	}
+
+	var result []Word
+	reviews, err := rs.ScheduleCardReviewNowWith(q, kind, n, pred)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range reviews {
+		result = append(result, Word{
+			Word: word,
+			New:  false,
+		})
+	}
+
+	words, err := getNewCardWordsWith(q, kind, n-len(reviews), pred)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, words...), nil
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Schedules lemmas instead of surface forms.
+// Review items are lemmas, so all inflections of a word share a review track.
+package word_scheduler
+
+import (
+	"github.com/polycloze/polycloze/database"
+	"github.com/polycloze/polycloze/difficulty"
+	rs "github.com/polycloze/polycloze/review_scheduler"
+)
+
+// Lemmas in the course, with the frequency class of their most common form.
+// Words that aren't in the `lemma` table are their own lemma.
+const lemmas = `
+	SELECT item, min(frequency_class) AS frequency_class, min(id) AS id FROM (
+		SELECT coalesce(lemma.lemma, word.word) AS item, frequency_class, id
+		FROM word LEFT JOIN lemma ON (lemma.word = word.id)
+	)
+	GROUP BY item
+`
+
+// Lemmas that have been reviewed.
+// Includes lemmas of inflected forms that were reviewed before lemma mode was
+// turned on.
+const reviewedLemmas = `
+	SELECT item FROM review
+	UNION
+	SELECT lemma.lemma FROM review
+	JOIN word ON (word.word = review.item)
+	JOIN lemma ON (lemma.word = word.id)
+`
+
+func getLemmasAboveDifficultyWith[T database.Querier](q T, n, preferredDifficulty int, pred func(word string) bool) ([]Word, error) {
+	query := `
+		SELECT item, frequency_class FROM (` + lemmas + `)
+		WHERE frequency_class >= ? AND item NOT IN (` + reviewedLemmas + `)
+		AND item NOT IN (SELECT item FROM hidden_item)
+		ORDER BY id ASC
+	`
+	rows, err := q.Query(query, preferredDifficulty)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return getNRows(rows, n, pred)
+}
+
+func getLemmasBelowDifficultyWith[T database.Querier](q T, n, preferredDifficulty int, pred func(word string) bool) ([]Word, error) {
+	query := `
+		SELECT item, frequency_class FROM (` + lemmas + `)
+		WHERE frequency_class < ? AND item NOT IN (` + reviewedLemmas + `)
+		AND item NOT IN (SELECT item FROM hidden_item)
+		ORDER BY id DESC
+	`
+	rows, err := q.Query(query, preferredDifficulty)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return getNRows(rows, n, pred)
+}
+
+// Like GetNewWordsWith, but returns lemmas.
+// Course DB must have a `lemma` table.
+func GetNewLemmasWith[T database.Querier](q T, n, preferredDifficulty int, pred func(word string) bool) ([]Word, error) {
+	words, err := getLemmasAboveDifficultyWith(q, n, preferredDifficulty, pred)
+	if err != nil {
+		return nil, err
+	}
+	if preferredDifficulty <= 0 || len(words) >= n {
+		return words, nil
+	}
+
+	more, err := getLemmasBelowDifficultyWith(q, n-len(words), preferredDifficulty, pred)
+	if err != nil {
+		return nil, err
+	}
+	return append(words, more...), nil
+}
+
+// Like GetWordsWith, but returns lemmas.
+// Course DB must have a `lemma` table.
+func GetLemmasWith[T database.Querier](q T, n int, pred func(word string) bool) ([]Word, error) {
+	return GetLemmasWithQuota(q, n, Unlimited, pred)
+}
+
+// Like GetWordsWithQuota, but returns lemmas.
+// Course DB must have a `lemma` table.
+func GetLemmasWithQuota[T database.Querier](q T, n int, quota Quota, pred func(word string) bool) ([]Word, error) {
+	var result []Word
+
+	reviews, err := rs.ScheduleReviewNowWith(q, capped(n, quota.Reviews), pred)
+	if err != nil {
+		return nil, err
+	}
+	for _, word := range reviews {
+		result = append(result, Word{
+			Word: word,
+			New:  false,
+		})
+	}
+
+	level := difficulty.GetLatest(q).Level
+	words, err := GetNewLemmasWith(q, capped(n-len(reviews), quota.NewWords), level, pred)
+	if err != nil {
+		return nil, err
+	}
+	return append(result, words...), nil
+}
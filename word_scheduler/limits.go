@@ -0,0 +1,146 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+// Daily limits on new words and reviews.
+package word_scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/polycloze/polycloze/database"
+)
+
+// Daily limits.
+// Non-positive values mean there's no limit.
+type Limits struct {
+	NewWords int
+	Reviews  int
+}
+
+// Number of new words and reviews left for the day.
+// Negative values mean there's no limit.
+type Quota struct {
+	NewWords int `json:"newWords"`
+	Reviews  int `json:"reviews"`
+}
+
+// Quota without limits.
+var Unlimited = Quota{NewWords: -1, Reviews: -1}
+
+// Returns the start of the study day that contains now.
+// The study day starts at rolloverHour in the given location.
+func DayStart(now time.Time, loc *time.Location, rolloverHour int) time.Time {
+	t := now.In(loc)
+	start := time.Date(t.Year(), t.Month(), t.Day(), rolloverHour, 0, 0, 0, loc)
+	if t.Before(start) {
+		start = start.AddDate(0, 0, -1)
+	}
+	return start
+}
+
+// Counts new words and reviews in the `history` table since start.
+func StudiedSince[T database.Querier](q T, start time.Time) (int, int, error) {
+	query := `
+		SELECT
+			coalesce(sum(interval_before IS NULL), 0),
+			coalesce(sum(interval_before IS NOT NULL), 0)
+		FROM history WHERE reviewed >= ?
+	`
+	var newWords, reviews int
+	if err := q.QueryRow(query, start.Unix()).Scan(&newWords, &reviews); err != nil {
+		return 0, 0, fmt.Errorf("failed to count today's reviews: %w", err)
+	}
+	return newWords, reviews, nil
+}
+
+// Counts words that were handed out to the student, but haven't been answered
+// yet.
+// Words that aren't in the `review` table count as new words, and words that
+// are due at now count as reviews. Answered words aren't due anymore, so they
+// don't get counted twice.
+func CountOutstanding[T database.Querier](q T, words []string, now time.Time) (int, int, error) {
+	if len(words) == 0 {
+		return 0, 0, nil
+	}
+	bytes, err := json.Marshal(words)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count outstanding words: %w", err)
+	}
+
+	query := `
+		SELECT
+			coalesce(sum(review.item IS NULL), 0),
+			coalesce(sum(review.due <= ?), 0)
+		FROM (SELECT DISTINCT value AS word FROM json_each(?))
+		LEFT JOIN review ON (review.item = word)
+	`
+	var newWords, reviews int
+	if err := q.QueryRow(query, now.Unix(), string(bytes)).Scan(&newWords, &reviews); err != nil {
+		return 0, 0, fmt.Errorf("failed to count outstanding words: %w", err)
+	}
+	return newWords, reviews, nil
+}
+
+// Uses up quota for new words and reviews that were already handed out.
+func (q Quota) Minus(newWords, reviews int) Quota {
+	if q.NewWords >= 0 {
+		q.NewWords = remaining(q.NewWords, newWords)
+	}
+	if q.Reviews >= 0 {
+		q.Reviews = remaining(q.Reviews, reviews)
+	}
+	return q
+}
+
+// Computes the remaining quota for the study day that started at dayStart.
+func GetQuota[T database.Querier](q T, limits Limits, dayStart time.Time) (Quota, error) {
+	quota := Unlimited
+	if limits.NewWords <= 0 && limits.Reviews <= 0 {
+		return quota, nil
+	}
+
+	newWords, reviews, err := StudiedSince(q, dayStart)
+	if err != nil {
+		return quota, err
+	}
+	if limits.NewWords > 0 {
+		quota.NewWords = remaining(limits.NewWords, newWords)
+	}
+	if limits.Reviews > 0 {
+		quota.Reviews = remaining(limits.Reviews, reviews)
+	}
+	return quota, nil
+}
+
+func remaining(limit, used int) int {
+	if used > limit {
+		return 0
+	}
+	return limit - used
+}
+
+// Returns the smaller of n and the remaining quota.
+func capped(n, remaining int) int {
+	if remaining >= 0 && remaining < n {
+		return remaining
+	}
+	return n
+}
+
+// Uses up quota for a word.
+// Returns false if there's no quota left for it.
+func (q *Quota) Take(word Word) bool {
+	remaining := &q.Reviews
+	if word.New {
+		remaining = &q.NewWords
+	}
+	if *remaining == 0 {
+		return false
+	}
+	if *remaining > 0 {
+		*remaining--
+	}
+	return true
+}
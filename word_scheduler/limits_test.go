@@ -0,0 +1,159 @@
+// Copyright (c) 2022 Levi Gruspe
+// License: GNU AGPLv3 or later
+
+package word_scheduler
+
+import (
+	"testing"
+	"time"
+
+	rs "github.com/polycloze/polycloze/review_scheduler"
+)
+
+func TestDayStart(t *testing.T) {
+	t.Parallel()
+
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	cases := []struct {
+		now      time.Time
+		expected time.Time
+	}{
+		{
+			now:      time.Date(2022, 1, 2, 5, 0, 0, 0, loc),
+			expected: time.Date(2022, 1, 2, 4, 0, 0, 0, loc),
+		},
+		{
+			// Late-night reviews count towards the previous day.
+			now:      time.Date(2022, 1, 2, 2, 0, 0, 0, loc),
+			expected: time.Date(2022, 1, 1, 4, 0, 0, 0, loc),
+		},
+		{
+			// Converts to the user's timezone first.
+			now:      time.Date(2022, 1, 1, 22, 0, 0, 0, time.UTC),
+			expected: time.Date(2022, 1, 2, 4, 0, 0, 0, loc),
+		},
+	}
+	for _, c := range cases {
+		if start := DayStart(c.now, loc, 4); !start.Equal(c.expected) {
+			t.Fatal("expected day start to be", c.expected, "got", start)
+		}
+	}
+}
+
+func TestGetQuota(t *testing.T) {
+	t.Parallel()
+
+	s := wordScheduler()
+	defer s.Close()
+
+	dayStart := time.Date(2022, 1, 2, 4, 0, 0, 0, time.UTC)
+
+	// Yesterday's review doesn't count.
+	if err := rs.UpdateReviewAt(s, "foo", true, dayStart.Add(-time.Hour)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	now := dayStart.Add(time.Hour)
+	for _, word := range []string{"foo", "bar", "baz"} {
+		if err := rs.UpdateReviewAt(s, word, true, now); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	quota, err := GetQuota(s, Limits{NewWords: 5, Reviews: 1}, dayStart)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if quota.NewWords != 3 || quota.Reviews != 0 {
+		t.Fatal("expected 3 new words and 0 reviews left:", quota)
+	}
+
+	quota, err = GetQuota(s, Limits{}, dayStart)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if quota != Unlimited {
+		t.Fatal("expected quota to be unlimited:", quota)
+	}
+}
+
+func TestGetWordsWithQuota(t *testing.T) {
+	t.Parallel()
+
+	s := wordScheduler()
+	defer s.Close()
+
+	query := `insert into word (word, frequency_class) values (?, ?)`
+	for _, word := range []string{"foo", "bar", "baz"} {
+		if _, err := s.Exec(query, word, 1); err != nil {
+			t.Fatal("expected err to be nil:", err)
+		}
+	}
+
+	pred := func(word string) bool { return true }
+	words, err := GetWordsWithQuota(s, 3, Quota{NewWords: 1, Reviews: -1}, pred)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(words) != 1 || !words[0].New {
+		t.Fatal("expected one new word:", words)
+	}
+
+	words, err = GetWordsWithQuota(s, 3, Quota{NewWords: 0, Reviews: -1}, pred)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if len(words) != 0 {
+		t.Fatal("expected no words when quota is used up:", words)
+	}
+}
+
+func TestQuotaTake(t *testing.T) {
+	t.Parallel()
+
+	quota := Quota{NewWords: 1, Reviews: -1}
+	if !quota.Take(Word{Word: "foo", New: true}) {
+		t.Fatal("expected new word to fit in quota")
+	}
+	if quota.Take(Word{Word: "bar", New: true}) {
+		t.Fatal("expected new word quota to be used up")
+	}
+	if !quota.Take(Word{Word: "baz"}) || quota.Reviews != -1 {
+		t.Fatal("expected review quota to stay unlimited:", quota)
+	}
+}
+
+func TestCountOutstanding(t *testing.T) {
+	// Unanswered new words and due reviews should be counted, but not words
+	// that were already answered.
+	t.Parallel()
+
+	s := wordScheduler()
+	defer s.Close()
+
+	now := time.Now()
+	if err := rs.UpdateReviewAt(s, "due", true, now.Add(-30*24*time.Hour)); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if err := rs.UpdateReviewAt(s, "answered", true, now); err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+
+	words := []string{"new", "due", "answered", "new"}
+	newWords, reviews, err := CountOutstanding(s, words, now)
+	if err != nil {
+		t.Fatal("expected err to be nil:", err)
+	}
+	if newWords != 1 || reviews != 1 {
+		t.Fatal("expected 1 new word and 1 review:", newWords, reviews)
+	}
+}
+
+func TestQuotaMinus(t *testing.T) {
+	t.Parallel()
+
+	quota := Quota{NewWords: 2, Reviews: -1}.Minus(3, 5)
+	if quota.NewWords != 0 || quota.Reviews != -1 {
+		t.Fatal("expected new word quota to be used up and reviews to stay unlimited:", quota)
+	}
+}
@@ -37,7 +37,7 @@ func getWordsAboveDifficultyWith[T database.Querier](q T, n, preferredDifficulty
 		FROM word
 		WHERE frequency_class >= ? AND word NOT IN (
 			SELECT item FROM review
-		)
+		) AND word NOT IN (SELECT item FROM hidden_item)
 		ORDER BY id ASC
 `
 	rows, err := q.Query(query, preferredDifficulty)
@@ -54,7 +54,7 @@ func getWordsBelowDifficultyWith[T database.Querier](q T, n, preferredDifficulty
 		FROM word
 		WHERE frequency_class < ? AND word NOT IN (
 			SELECT item FROM review
-		)
+		) AND word NOT IN (SELECT item FROM hidden_item)
 		ORDER BY id DESC
 `
 	rows, err := q.Query(query, preferredDifficulty)
@@ -39,9 +39,15 @@ func GetWordsAt[T database.Querier](q T, n int, due time.Time) ([]Word, error) {
 // Returns up to words to make flashcards for.
 // Only includes words that satisfy the predicate.
 func GetWordsWith[T database.Querier](q T, n int, pred func(word string) bool) ([]Word, error) {
+	return GetWordsWithQuota(q, n, Unlimited, pred)
+}
+
+// Like GetWordsWith, but doesn't return more new words and reviews than the
+// quota allows.
+func GetWordsWithQuota[T database.Querier](q T, n int, quota Quota, pred func(word string) bool) ([]Word, error) {
 	var result []Word
 
-	reviews, err := rs.ScheduleReviewNowWith(q, n, pred)
+	reviews, err := rs.ScheduleReviewNowWith(q, capped(n, quota.Reviews), pred)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +59,7 @@ func GetWordsWith[T database.Querier](q T, n int, pred func(word string) bool) (
 	}
 
 	level := difficulty.GetLatest(q).Level
-	words, err := GetNewWordsWith(q, n-len(reviews), level, pred)
+	words, err := GetNewWordsWith(q, capped(n-len(reviews), quota.NewWords), level, pred)
 	if err != nil {
 		return nil, err
 	}
@@ -80,11 +86,18 @@ func UpdateWordAt[T database.Querier](q T, word string, correct bool, at time.Ti
 
 type ReviewResult = rs.Result
 
+// Saves word review result using the given scheduler.
+// See SaveReviewAt.
+func SaveWordAt[T database.Querier](q T, s rs.Scheduler, review ReviewResult, at time.Time) error {
+	review.Word = text.Casefold(review.Word)
+	return rs.SaveReviewAt(q, s, review, at)
+}
+
 // Saves word review results in bulk.
-func BulkSaveWords[T database.Querier](q T, reviews []ReviewResult, at time.Time) error {
+func BulkSaveWords[T database.Querier](q T, s rs.Scheduler, reviews []ReviewResult, at time.Time) error {
 	// Client already casefolds words, but let's casefold again to be sure.
 	for i, review := range reviews {
 		reviews[i].Word = text.Casefold(review.Word)
 	}
-	return rs.BulkSaveReviews(q, reviews, at)
+	return rs.BulkSaveReviews(q, s, reviews, at)
 }